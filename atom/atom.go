@@ -0,0 +1,169 @@
+// The atom package provides Atom, a mutable reference to an immutable
+// value such as an immut.Seq, in the style of Clojure atoms.  Any number
+// of goroutines can safely read and update an Atom.
+package atom
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"sync"
+	"sync/atomic"
+)
+
+// An Atom holds a value that is replaced, never modified, on each
+// update.
+type Atom struct {
+	state atomic.Pointer[box]
+
+	mu        sync.Mutex // guards the fields below
+	validator func(interface{}) error
+	watches   map[interface{}]Watcher
+}
+
+// A Watcher is called after each change to the value of an Atom, with
+// the key it was added with and the old and new values.  Watchers may be
+// called concurrently from different goroutines, and two changes may
+// be reported out of order.
+type Watcher func(key interface{}, a *Atom, old, new interface{})
+
+// Create a new Atom holding x.
+func New(x interface{}) *Atom {
+	a := &Atom{}
+	a.state.Store(&box{x})
+	return a
+}
+
+// Deref returns the current value. O(1), never blocks.
+func (a *Atom) Deref() interface{} { return a.state.Load().value }
+
+// Swap atomically replaces the value x with f(x), returning the new
+// value.  If another goroutine changes the value first, f is called
+// again with the newer value, so f may be called more than once and
+// should have no side effects.  If the validator rejects the new value
+// the value is unchanged and the validator's error is returned.
+func (a *Atom) Swap(f func(interface{}) interface{}) (interface{}, error) {
+	for {
+		old := a.state.Load()
+		x := f(old.value)
+		if err := a.validate(x); err != nil {
+			return old.value, err
+		}
+		if a.state.CompareAndSwap(old, &box{x}) {
+			a.notify(old.value, x)
+			return x, nil
+		}
+	}
+}
+
+// Reset replaces the value with x, regardless of the current value.
+func (a *Atom) Reset(x interface{}) error {
+	if err := a.validate(x); err != nil {
+		return err
+	}
+	old := a.state.Swap(&box{x})
+	a.notify(old.value, x)
+	return nil
+}
+
+// CompareAndSet replaces the value with x only if the current value is
+// identical to old, returning whether it did.  Values that are not
+// comparable, such as vectors, are identical only if they are the very
+// same version, and functions are never identical, as for
+// immut.Identical.
+func (a *Atom) CompareAndSet(old, x interface{}) (bool, error) {
+	if err := a.validate(x); err != nil {
+		return false, err
+	}
+	for {
+		cur := a.state.Load()
+		if !immut.Identical(cur.value, old) {
+			return false, nil
+		}
+		if a.state.CompareAndSwap(cur, &box{x}) {
+			a.notify(cur.value, x)
+			return true, nil
+		}
+	}
+}
+
+// SetValidator sets the function that checks every new value, rejecting
+// it by returning an error.  The current value is checked first, and if
+// it is rejected the validator is not set.  A nil validator accepts
+// everything.
+func (a *Atom) SetValidator(validator func(interface{}) error) error {
+	if validator != nil {
+		if err := validator(a.Deref()); err != nil {
+			return err
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.validator = validator
+	return nil
+}
+
+// AddWatch adds a Watcher, replacing any already added with the same key.
+func (a *Atom) AddWatch(key interface{}, w Watcher) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	watches := make(map[interface{}]Watcher, len(a.watches)+1)
+	for k, v := range a.watches {
+		watches[k] = v
+	}
+	watches[key] = w
+	a.watches = watches
+}
+
+// RemoveWatch removes the Watcher added with key.
+func (a *Atom) RemoveWatch(key interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	watches := make(map[interface{}]Watcher, len(a.watches))
+	for k, v := range a.watches {
+		watches[k] = v
+	}
+	delete(watches, key)
+	a.watches = watches
+}
+
+// Everything below here is private
+
+// The value is boxed so that values that are not comparable can be
+// swapped, and so that storing the same value twice is still a change.
+type box struct {
+	value interface{}
+}
+
+func (a *Atom) validate(x interface{}) error {
+	a.mu.Lock()
+	validator := a.validator
+	a.mu.Unlock()
+	if validator == nil {
+		return nil
+	}
+	return validator(x)
+}
+
+// The watches map is replaced, never modified, so it can be ranged over
+// without holding the lock.
+func (a *Atom) notify(old, x interface{}) {
+	a.mu.Lock()
+	watches := a.watches
+	a.mu.Unlock()
+	for key, w := range watches {
+		w(key, a, old, x)
+	}
+}
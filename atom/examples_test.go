@@ -0,0 +1,79 @@
+package atom_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/atom"
+	"github.com/eobrain/immut/ordered"
+	"github.com/eobrain/immut/vector"
+	"sync"
+)
+
+func Example() {
+	a := atom.New(ordered.New())
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a.Swap(func(xs interface{}) interface{} {
+				return xs.(immut.Seq).AddFront(i)
+			})
+		}(i)
+	}
+	wg.Wait()
+	fmt.Println(a.Deref().(immut.Seq).Len())
+	// Output:
+	// 100
+}
+
+func ExampleAtom_CompareAndSet() {
+	v1 := vector.New(1, 2)
+	a := atom.New(v1)
+	v2 := v1.AddBack(3)
+	fmt.Println(a.CompareAndSet(vector.New(1, 2), v2)) // equal but not identical
+	fmt.Println(a.CompareAndSet(v1, v2))
+	fmt.Println(a.Deref())
+	// Output:
+	// false <nil>
+	// true <nil>
+	// [1,2,3]
+}
+
+func ExampleAtom_SetValidator() {
+	a := atom.New(ordered.New(1, 2))
+	a.SetValidator(func(x interface{}) error {
+		if x.(immut.Seq).Len() > 3 {
+			return errors.New("too big")
+		}
+		return nil
+	})
+	grow := func(x interface{}) interface{} {
+		xs := x.(immut.Seq)
+		return xs.AddFront(xs.Len() + 1)
+	}
+	fmt.Println(a.Swap(grow))
+	fmt.Println(a.Swap(grow))
+	fmt.Println(a.Deref())
+	// Output:
+	// {1,2,3} <nil>
+	// {1,2,3} too big
+	// {1,2,3}
+}
+
+func ExampleAtom_AddWatch() {
+	a := atom.New(vector.New())
+	a.AddWatch("log", func(key interface{}, _ *atom.Atom, old, new interface{}) {
+		fmt.Println(key, old, "->", new)
+	})
+	a.Reset(vector.New("x"))
+	a.Swap(func(x interface{}) interface{} {
+		return x.(immut.Seq).AddBack("y")
+	})
+	a.RemoveWatch("log")
+	a.Reset(vector.New())
+	// Output:
+	// log [] -> [x]
+	// log [x] -> [x,y]
+}
@@ -0,0 +1,136 @@
+// The bimap package provides persistent bidirectional maps, in which
+// each key has one value and each value has one key, so that either can
+// be looked up from the other.
+package bimap
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/unordered"
+)
+
+// Create a new bidirectional map from alternating keys and values,
+// where later pairs replace earlier ones with the same key or value.
+// O(n*log(n))
+func New(keyValues ...interface{}) *BiMap {
+	if len(keyValues)%2 != 0 {
+		panic("odd number of arguments to New")
+	}
+	return NewHashed(nil, nil, keyValues...)
+}
+
+// Create a new bidirectional map from alternating keys and values, with
+// keys hashed by one Hasher and values by the other, or by
+// unordered.DefaultHasher for either that is nil. O(n*log(n))
+func NewHashed(keys, values unordered.Hasher, keyValues ...interface{}) *BiMap {
+	if len(keyValues)%2 != 0 {
+		panic("odd number of arguments to NewHashed")
+	}
+	b := &BiMap{unordered.NewHashedMap(keys), unordered.NewHashedMap(values)}
+	for i := 0; i < len(keyValues); i += 2 {
+		b = b.Put(keyValues[i], keyValues[i+1])
+	}
+	return b
+}
+
+// A BiMap is a Map that also maps its values back to their keys.  It is
+// kept as two hashed maps, one in each direction, which are always
+// updated together.
+type BiMap struct {
+	forward, backward immut.Map
+}
+
+// Put returns the map with the key mapped to the value, after removing
+// the pair with the key and the pair with the value if there are any.
+// O(log n)
+func (b *BiMap) Put(key, value interface{}) *BiMap {
+	if v, ok := b.forward.Get(key); ok {
+		if k, _ := b.backward.Get(value); immut.Identical(v, value) && immut.Identical(k, key) {
+			return b
+		}
+	}
+	forward, backward := b.forward, b.backward
+	if v, ok := forward.Get(key); ok {
+		backward = backward.Dissoc(v)
+	}
+	if k, ok := backward.Get(value); ok {
+		forward = forward.Dissoc(k)
+	}
+	return &BiMap{forward.Assoc(key, value), backward.Assoc(value, key)}
+}
+
+// GetByKey returns the value of the key, or false if it has none.
+// O(log n)
+func (b *BiMap) GetByKey(key interface{}) (interface{}, bool) { return b.forward.Get(key) }
+
+// GetByValue returns the key of the value, or false if it has none.
+// O(log n)
+func (b *BiMap) GetByValue(value interface{}) (interface{}, bool) { return b.backward.Get(value) }
+
+// RemoveKey returns the map without the pair with the key, or the map
+// itself if there is none. O(log n)
+func (b *BiMap) RemoveKey(key interface{}) *BiMap {
+	v, ok := b.forward.Get(key)
+	if !ok {
+		return b
+	}
+	return &BiMap{b.forward.Dissoc(key), b.backward.Dissoc(v)}
+}
+
+// RemoveValue returns the map without the pair with the value, or the
+// map itself if there is none. O(log n)
+func (b *BiMap) RemoveValue(value interface{}) *BiMap {
+	return b.Inverse().RemoveKey(value).Inverse()
+}
+
+// Inverse returns the map from values to keys, sharing both directions
+// with this one. O(1)
+func (b *BiMap) Inverse() *BiMap { return &BiMap{b.backward, b.forward} }
+
+// Values returns the values as a Seq. O(1)
+func (b *BiMap) Values() immut.Seq { return b.backward.Keys() }
+
+// O(1)
+func (b *BiMap) Len() int { return b.forward.Len() }
+
+// O(log n)
+func (b *BiMap) Get(key interface{}) (interface{}, bool) { return b.GetByKey(key) }
+
+// Assoc is Put, removing any other pair with the value. O(log n)
+func (b *BiMap) Assoc(key, value interface{}) immut.Map { return b.Put(key, value) }
+
+// O(log n)
+func (b *BiMap) Dissoc(key interface{}) immut.Map { return b.RemoveKey(key) }
+
+// Keys returns the keys as a Seq. O(1)
+func (b *BiMap) Keys() immut.Seq { return b.forward.Keys() }
+
+// O(n)
+func (b *BiMap) Do(f func(key, value interface{})) { b.forward.Do(f) }
+
+func (b *BiMap) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	sep := ""
+	b.Do(func(k, v interface{}) {
+		fmt.Fprintf(&buf, "%s%v:%v", sep, k, v)
+		sep = ","
+	})
+	buf.WriteString("}")
+	return buf.String()
+}
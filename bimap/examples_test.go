@@ -0,0 +1,43 @@
+package bimap_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/bimap"
+)
+
+func Example() {
+	ids := bimap.New("alice", 1, "bob", 2)
+	renamed := ids.Put("carol", 2)
+
+	fmt.Println(ids.GetByValue(2))
+	fmt.Println(renamed.GetByValue(2))
+	fmt.Println(renamed.GetByKey("bob"))
+	fmt.Println(renamed.Len(), ids.Len())
+	// Output:
+	// bob true
+	// carol true
+	// <nil> false
+	// 2 2
+}
+
+func ExampleBiMap_Inverse() {
+	codes := bimap.New("GB", 44)
+	byCode := codes.Inverse()
+	fmt.Println(byCode.GetByKey(44))
+	fmt.Println(byCode.Put(1, "US").Inverse().GetByKey("US"))
+	// Output:
+	// GB true
+	// 1 true
+}
+
+func ExampleBiMap_Put() {
+	seats := bimap.New("ann", "1A", "ben", "1B")
+	// ann moves to ben's seat, so ben loses it and 1A is free
+	seats = seats.Put("ann", "1B")
+	fmt.Println(seats, seats.Len())
+	_, taken := seats.GetByValue("1A")
+	fmt.Println(taken)
+	// Output:
+	// {ann:1B} 1
+	// false
+}
@@ -0,0 +1,240 @@
+// The codec package writes immut collections to a stream and reads them
+// back, writing the nodes that versions of a collection share only once
+// and restoring them shared.
+package codec
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/gob"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/list"
+	"github.com/eobrain/immut/ordered"
+	"github.com/eobrain/immut/unordered"
+	"github.com/eobrain/immut/vector"
+	"io"
+	"reflect"
+)
+
+// An Encoder writes Seqs to a stream.  List cells and tree nodes written
+// by one call of Encode are referred to by ID, rather than written again,
+// by any later call on the same Encoder.  The items themselves are
+// written with encoding/gob, so their types must be registered with gob
+// if they are not builtin types.
+type Encoder struct {
+	enc *gob.Encoder
+	ids map[interface{}]int
+	n   int
+}
+
+// A Decoder reads Seqs written by an Encoder, restoring the sharing
+// between them.
+type Decoder struct {
+	dec   *gob.Decoder
+	nodes []immut.Seq
+}
+
+// Create an Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{gob.NewEncoder(w), make(map[interface{}]int), 0}
+}
+
+// Create a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{gob.NewDecoder(r), nil}
+}
+
+// Encode writes xs to the stream, along with whichever of its nodes have
+// not already been written.
+func (e *Encoder) Encode(xs immut.Seq) error {
+	id, err := e.node(xs)
+	if err != nil {
+		return err
+	}
+	return e.enc.Encode(record{Kind: root, Left: id})
+}
+
+// Decode reads the next Seq from the stream.
+func (d *Decoder) Decode() (immut.Seq, error) {
+	for {
+		var rec record
+		if err := d.dec.Decode(&rec); err != nil {
+			return nil, err
+		}
+		if rec.Kind == root {
+			return d.ref(rec.Left)
+		}
+		xs, err := d.build(rec)
+		if err != nil {
+			return nil, err
+		}
+		d.nodes = append(d.nodes, xs)
+	}
+}
+
+// Everything below here is private
+
+type kind byte
+
+const (
+	root kind = iota
+	listEmpty
+	listCons
+	vectorEmpty
+	vectorSlice
+	orderedEmpty
+	orderedTree
+	unorderedEmpty
+	unorderedSet
+)
+
+// One record is written for each node, and one for each root.  Left and
+// Right are the IDs of child nodes, which are always written before
+// their parents.
+type record struct {
+	Kind        kind
+	Value       interface{}
+	Items       []interface{}
+	Left, Right int
+}
+
+// The concrete types are not exported, so recognize them by the types
+// of sample values.
+var kinds = map[reflect.Type]kind{
+	reflect.TypeOf(list.New()):       listEmpty,
+	reflect.TypeOf(list.New(0)):      listCons,
+	reflect.TypeOf(vector.New()):     vectorEmpty,
+	reflect.TypeOf(vector.New(0)):    vectorSlice,
+	reflect.TypeOf(ordered.New()):    orderedEmpty,
+	reflect.TypeOf(ordered.New(0)):   orderedTree,
+	reflect.TypeOf(unordered.New()):  unorderedEmpty,
+	reflect.TypeOf(unordered.New(0)): unorderedSet,
+}
+
+// Write the node xs and whatever it refers to, returning its ID.
+func (e *Encoder) node(xs immut.Seq) (int, error) {
+	k, ok := kinds[reflect.TypeOf(xs)]
+	if !ok {
+		return 0, fmt.Errorf("codec: cannot encode %T", xs)
+	}
+	switch k {
+	case vectorSlice, unorderedSet:
+		// not comparable, so cannot be shared
+		return e.write(nil, record{Kind: k, Items: xs.Items()})
+	case listCons:
+		return e.list(xs)
+	case orderedTree:
+		return e.tree(xs.(*ordered.Tree))
+	default:
+		// one of the empty values
+		if id, ok := e.ids[xs]; ok {
+			return id, nil
+		}
+		return e.write(xs, record{Kind: k})
+	}
+}
+
+// Write the cells of a list iteratively, as lists can be long.
+func (e *Encoder) list(xs immut.Seq) (id int, err error) {
+	var cells []immut.Seq
+	for {
+		if kinds[reflect.TypeOf(xs)] != listCons {
+			id, err = e.node(xs)
+			break
+		}
+		if known, ok := e.ids[xs]; ok {
+			id = known
+			break
+		}
+		cells = append(cells, xs)
+		xs = xs.Rest()
+	}
+	for i := len(cells) - 1; i >= 0 && err == nil; i-- {
+		id, err = e.write(cells[i],
+			record{Kind: listCons, Value: cells[i].Front(), Right: id})
+	}
+	return
+}
+
+func (e *Encoder) tree(xs *ordered.Tree) (int, error) {
+	if id, ok := e.ids[xs]; ok {
+		return id, nil
+	}
+	left, err := e.node(xs.Left())
+	if err != nil {
+		return 0, err
+	}
+	right, err := e.node(xs.Right())
+	if err != nil {
+		return 0, err
+	}
+	return e.write(xs,
+		record{Kind: orderedTree, Value: xs.Value(), Left: left, Right: right})
+}
+
+// Write a node record, remembering its ID if it can be shared.
+func (e *Encoder) write(xs immut.Seq, rec record) (int, error) {
+	if err := e.enc.Encode(rec); err != nil {
+		return 0, err
+	}
+	id := e.n
+	e.n++
+	if xs != nil {
+		e.ids[xs] = id
+	}
+	return id, nil
+}
+
+func (d *Decoder) ref(id int) (immut.Seq, error) {
+	if id < 0 || id >= len(d.nodes) {
+		return nil, fmt.Errorf("codec: reference to unknown node %d", id)
+	}
+	return d.nodes[id], nil
+}
+
+func (d *Decoder) build(rec record) (immut.Seq, error) {
+	switch rec.Kind {
+	case listEmpty:
+		return list.New(), nil
+	case vectorEmpty:
+		return vector.New(), nil
+	case orderedEmpty:
+		return ordered.New(), nil
+	case unorderedEmpty:
+		return unordered.New(), nil
+	case vectorSlice:
+		return vector.New(rec.Items...), nil
+	case unorderedSet:
+		return unordered.New(rec.Items...), nil
+	case listCons:
+		rest, err := d.ref(rec.Right)
+		if err != nil {
+			return nil, err
+		}
+		return list.Cons(rec.Value, rest), nil
+	case orderedTree:
+		left, err := d.ref(rec.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.ref(rec.Right)
+		if err != nil {
+			return nil, err
+		}
+		return ordered.Node(left, rec.Value, right), nil
+	}
+	return nil, fmt.Errorf("codec: unknown record kind %d", rec.Kind)
+}
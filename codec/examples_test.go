@@ -0,0 +1,110 @@
+package codec_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/codec"
+	"github.com/eobrain/immut/list"
+	"github.com/eobrain/immut/ordered"
+	"github.com/eobrain/immut/unordered"
+	"github.com/eobrain/immut/vector"
+)
+
+func Example() {
+	var buf bytes.Buffer
+	enc := codec.NewEncoder(&buf)
+	v1 := list.New(2, 3, 4)
+	v2 := v1.AddFront(1)
+	enc.Encode(v1)
+	enc.Encode(v2)
+
+	dec := codec.NewDecoder(&buf)
+	w1, _ := dec.Decode()
+	w2, _ := dec.Decode()
+	fmt.Println(w1)
+	fmt.Println(w2)
+	fmt.Println(w2.Rest() == w1) // shared, not copied
+
+	// Output:
+	// [2,3,4]
+	// [1,2,3,4]
+	// true
+}
+
+func ExampleEncoder_Encode() {
+	var buf bytes.Buffer
+	enc := codec.NewEncoder(&buf)
+	seqs := []immut.Seq{
+		list.New(),
+		list.New(1, 2, 3),
+		list.New(1, 2).AddAll(vector.New(3, 4)),
+		vector.New(),
+		vector.New(1, 2, 3),
+		ordered.New(),
+		ordered.New("b", "c", "a"),
+		unordered.New(),
+		unordered.New(42),
+	}
+	for _, xs := range seqs {
+		if err := enc.Encode(xs); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	dec := codec.NewDecoder(&buf)
+	for range seqs {
+		xs, err := dec.Decode()
+		if err != nil {
+			fmt.Println(err)
+		}
+		fmt.Println(xs)
+	}
+
+	// Output:
+	// []
+	// [1,2,3]
+	// [1,2,3,4]
+	// []
+	// [1,2,3]
+	// {}
+	// {a,b,c}
+	// {}
+	// {42}
+}
+
+func ExampleDecoder_Decode() {
+	var buf bytes.Buffer
+	enc := codec.NewEncoder(&buf)
+	v1 := ordered.New(5, 3, 8, 1, 4)
+	v2 := v1.AddFront(9)
+	enc.Encode(v1)
+	enc.Encode(v2)
+
+	dec := codec.NewDecoder(&buf)
+	w1, _ := dec.Decode()
+	w2, _ := dec.Decode()
+	fmt.Println(w1, w2)
+	fmt.Println(w1.(*ordered.Tree).Left() == w2.(*ordered.Tree).Left())
+
+	// Output:
+	// {1,3,4,5,8} {1,3,4,5,8,9}
+	// true
+}
+
+func Example_gob() {
+	type doc struct {
+		Title string
+		Tags  immut.Seq
+	}
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(doc{"hello", ordered.New("b", "a")})
+
+	var d doc
+	gob.NewDecoder(&buf).Decode(&d)
+	fmt.Println(d.Title, d.Tags)
+
+	// Output:
+	// hello {a,b}
+}
@@ -0,0 +1,142 @@
+package immut_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/list"
+	"github.com/eobrain/immut/ordered"
+	"github.com/eobrain/immut/unordered"
+	"github.com/eobrain/immut/vector"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// Run these with "go test -race" to check the concurrency contract in
+// the package documentation.
+
+const (
+	goroutines = 32
+	shared     = 200
+)
+
+func sharedSeqs() map[string]immut.Seq {
+	items := make([]interface{}, shared)
+	for i := range items {
+		items[i] = i
+	}
+	return map[string]immut.Seq{
+		"list":      list.New(items...),
+		"vector":    vector.New(items...),
+		"ordered":   ordered.New(items...),
+		"unordered": unordered.New(items...),
+	}
+}
+
+// Many goroutines reading and deriving new versions from one shared
+// version must neither race nor change what the shared version holds.
+func TestConcurrentReaders(t *testing.T) {
+	for name, xs := range sharedSeqs() {
+		want := sorted(xs)
+		var wg sync.WaitGroup
+		errs := make(chan error, goroutines)
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				errs <- hammer(xs, g)
+			}(g)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}
+		if got := sorted(xs); got != want {
+			t.Errorf("%s: shared version changed", name)
+		}
+	}
+}
+
+// Versions derived concurrently from the same parent must each see only
+// their own changes.
+func TestConcurrentVersions(t *testing.T) {
+	for name, xs := range sharedSeqs() {
+		versions := make([]immut.Seq, goroutines)
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				ys := xs.AddFront(-g - 1).AddBack(shared + g)
+				ys = ys.AddAll(xs).Remove(g)
+				versions[g] = ys
+			}(g)
+		}
+		wg.Wait()
+		for g, ys := range versions {
+			if !ys.Contains(-g-1) || !ys.Contains(shared+g) {
+				t.Errorf("%s: version %d lost its own items", name, g)
+			}
+			if ys.Contains(-g-2) || ys.Contains(shared+g+1) {
+				t.Errorf("%s: version %d has another version's items", name, g)
+			}
+		}
+		if xs.Len() != shared {
+			t.Errorf("%s: shared version has %d items", name, xs.Len())
+		}
+	}
+}
+
+func hammer(xs immut.Seq, g int) error {
+	n := xs.Len()
+	if n != shared {
+		return fmt.Errorf("Len()=%d", n)
+	}
+	count := 0
+	xs.Do(func(interface{}) { count++ })
+	xs.DoBackwards(func(interface{}) { count++ })
+	if count != 2*n {
+		return fmt.Errorf("visited %d items", count)
+	}
+	for i := g; i < n; i += goroutines {
+		if !xs.Contains(i) {
+			return fmt.Errorf("Contains(%d) false", i)
+		}
+		if _, ok := xs.Get(i); !ok {
+			return fmt.Errorf("Get(%d) false", i)
+		}
+	}
+	seen := 0
+	for ys := xs; !ys.IsEmpty(); ys = ys.Rest() {
+		ys.Front()
+		seen++
+		if seen > 50 {
+			break
+		}
+	}
+	both := xs.AddAll(xs.Map(func(x interface{}) interface{} { return x.(int) + n }))
+	if both.Len() != 2*n {
+		return fmt.Errorf("AddAll gave %d items", both.Len())
+	}
+	if len(xs.Items()) != n || len(xs.Filter(isEven).Items()) != n/2 {
+		return fmt.Errorf("Items or Filter wrong")
+	}
+	immut.Join(xs, ",")
+	immut.Diff(xs, xs.Remove(g))
+	xs.Reverse()
+	xs.Back()
+	return nil
+}
+
+// The items in a canonical order, as unordered sets have none.
+func sorted(xs immut.Seq) string {
+	items := make([]int, 0, xs.Len())
+	xs.Do(func(x interface{}) { items = append(items, x.(int)) })
+	sort.Ints(items)
+	return fmt.Sprint(items)
+}
+
+func isEven(x interface{}) bool { return x.(int)%2 == 0 }
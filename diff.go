@@ -0,0 +1,229 @@
+package immut
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A Delta describes how to turn one version of a collection into
+// another.  Set differences are given by Removed and Added, sequence
+// differences by Edits.
+type Delta struct {
+	// Items in the old set but not the new one.
+	Removed []interface{}
+
+	// Items in the new set but not the old one.
+	Added []interface{}
+
+	// Edits to apply in order.  The Index of each edit is a position in
+	// the sequence as changed by the edits before it.
+	Edits []Edit
+}
+
+// An Edit inserts or deletes one item in a sequence.
+type Edit struct {
+	Op    EditOp
+	Index int
+	Item  interface{}
+}
+
+// An EditOp is the kind of an Edit.
+type EditOp int
+
+const (
+	// Insert Item so that it is at Index.
+	Insert EditOp = iota
+	// Delete Item, which is at Index.
+	Delete
+)
+
+// A Differ is a Seq that can find its differences from a later version
+// of itself more cheaply than by comparing every item, for example by
+// skipping over the nodes the two versions share.
+type Differ interface {
+	Diff(newer Seq) Delta
+}
+
+// Diff returns the Delta that turns older into newer.  If older is a
+// Differ then its Diff method is used, otherwise this returns a minimal
+// edit script found by comparing the items. O((n+m)*d) time, where d is
+// the number of edits, and O(n+m) space.
+func Diff(older, newer Seq) Delta {
+	if d, ok := older.(Differ); ok {
+		return d.Diff(newer)
+	}
+	return Delta{Edits: editScript(older.Items(), newer.Items())}
+}
+
+// Patch applies d to xs, returning a new Seq of the same kind.  If d was
+// returned by Diff(xs, ys) the result has the same items as ys.  The
+// result shares whatever part of the end of xs is unchanged.
+func Patch(xs Seq, d Delta) Seq {
+	for _, x := range d.Removed {
+		xs = xs.Remove(x)
+	}
+	for _, x := range d.Added {
+		xs = xs.AddFront(x)
+	}
+	if len(d.Edits) == 0 {
+		return xs
+	}
+	olds := xs.Items()
+	news := make([]interface{}, len(olds))
+	copy(news, olds)
+	for _, e := range d.Edits {
+		switch e.Op {
+		case Insert:
+			news = append(news, nil)
+			copy(news[e.Index+1:], news[e.Index:])
+			news[e.Index] = e.Item
+		case Delete:
+			news = append(news[:e.Index], news[e.Index+1:]...)
+		}
+	}
+	return rebuild(xs, olds, news)
+}
+
+// Everything below here is private
+
+// Return a Seq of the same kind as xs, whose items are olds, but with
+// items news instead, sharing the longest common suffix with xs.
+func rebuild(xs Seq, olds, news []interface{}) Seq {
+	k := 0
+	for k < len(olds) && k < len(news) &&
+		Identical(olds[len(olds)-1-k], news[len(news)-1-k]) {
+		k++
+	}
+	for i := len(olds) - k; i > 0; i-- {
+		xs = xs.Rest()
+	}
+	for i := len(news) - k - 1; i >= 0; i-- {
+		xs = xs.AddFront(news[i])
+	}
+	return xs
+}
+
+// Myers' difference algorithm in linear space: find the middle of a
+// shortest edit script by searching forward from the start and back
+// from the end at once, then recurse on either side of it.
+// O((n+m)*d) time and O(n+m) space
+func editScript(a, b []interface{}) []Edit {
+	s := differ{a: a, b: b}
+	s.diff(0, len(a), 0, len(b))
+	return s.edits
+}
+
+type differ struct {
+	a, b  []interface{}
+	edits []Edit
+}
+
+// Append the edits turning a[x0:x1] into b[y0:y1], where the Index of
+// an edit is where it is in b, as the edits before it have already
+// turned a[:x0] into b[:y0]
+func (s *differ) diff(x0, x1, y0, y1 int) {
+	for x0 < x1 && y0 < y1 && Identical(s.a[x0], s.b[y0]) {
+		x0++
+		y0++
+	}
+	for x0 < x1 && y0 < y1 && Identical(s.a[x1-1], s.b[y1-1]) {
+		x1--
+		y1--
+	}
+	switch {
+	case x0 == x1:
+		for y := y0; y < y1; y++ {
+			s.edits = append(s.edits, Edit{Insert, y, s.b[y]})
+		}
+	case y0 == y1:
+		for x := x0; x < x1; x++ {
+			s.edits = append(s.edits, Edit{Delete, y0, s.a[x]})
+		}
+	default:
+		x, y := s.middle(x0, x1, y0, y1)
+		s.diff(x0, x, y0, y)
+		s.diff(x, x1, y, y1)
+	}
+}
+
+// Return a point that a shortest edit script from (x0,y0) to (x1,y1)
+// passes through, near its middle.  The ends differ, so d > 1 and the
+// point splits the script into two shorter ones.
+func (s *differ) middle(x0, x1, y0, y1 int) (int, int) {
+	n, m := x1-x0, y1-y0
+	lim := (n + m + 1) / 2
+	// fwd[lim+k] is the furthest x reached forward on diagonal k = x - y,
+	// and bwd[lim+k] the furthest back from the end, or -1 if neither
+	// has been reached yet
+	fwd, bwd := make([]int, 2*lim+2), make([]int, 2*lim+2)
+	for i := range fwd {
+		fwd[i], bwd[i] = -1, -1
+	}
+	fwd[lim+1], bwd[lim+1] = 0, 0
+	delta := n - m
+	odd := delta%2 != 0
+	// diagonals that have run off the edges and need not be searched
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for d := 0; d < lim; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || k != d && fwd[lim+k-1] < fwd[lim+k+1] {
+				x = fwd[lim+k+1]
+			} else {
+				x = fwd[lim+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && Identical(s.a[x0+x], s.b[y0+y]) {
+				x++
+				y++
+			}
+			fwd[lim+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if j := lim + delta - k; 0 <= j && j < len(bwd) && bwd[j] != -1 && x >= n-bwd[j] {
+					return x0 + x, y0 + y
+				}
+			}
+		}
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			var x int
+			if k == -d || k != d && bwd[lim+k-1] < bwd[lim+k+1] {
+				x = bwd[lim+k+1]
+			} else {
+				x = bwd[lim+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && Identical(s.a[x1-x-1], s.b[y1-y-1]) {
+				x++
+				y++
+			}
+			bwd[lim+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if j := lim + delta - k; 0 <= j && j < len(fwd) && fwd[j] != -1 && fwd[j] >= n-x {
+					fx := fwd[j]
+					return x0 + fx, y0 + fx - (delta - k)
+				}
+			}
+		}
+	}
+	// only reached if nothing is in common, so delete all then insert all
+	return x1, y0
+}
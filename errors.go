@@ -0,0 +1,69 @@
+package immut
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEmpty is returned when asking for an item of an empty Seq.
+var ErrEmpty = errors.New("immut: empty seq")
+
+// An IndexError reports an index outside a Seq.
+type IndexError struct {
+	Index, Len int
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("immut: index %d out of range for seq of length %d",
+		e.Index, e.Len)
+}
+
+// TryFront is like xs.Front(), but returns ErrEmpty rather than
+// panicking if xs is empty.
+func TryFront(xs Seq) (interface{}, error) {
+	if xs.IsEmpty() {
+		return nil, ErrEmpty
+	}
+	return xs.Front(), nil
+}
+
+// TryBack is like xs.Back(), but returns ErrEmpty rather than panicking
+// if xs is empty.
+func TryBack(xs Seq) (interface{}, error) {
+	if xs.IsEmpty() {
+		return nil, ErrEmpty
+	}
+	return xs.Back(), nil
+}
+
+// TryRest is like xs.Rest(), but returns ErrEmpty rather than panicking
+// if xs is empty.
+func TryRest(xs Seq) (Seq, error) {
+	if xs.IsEmpty() {
+		return nil, ErrEmpty
+	}
+	return xs.Rest(), nil
+}
+
+// TryGet is like xs.Get(i), but returns an *IndexError if i is out of
+// range.
+func TryGet(xs Seq, i int) (interface{}, error) {
+	if x, ok := xs.Get(i); ok {
+		return x, nil
+	}
+	return nil, &IndexError{i, xs.Len()}
+}
@@ -1,6 +1,7 @@
 package immut_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/eobrain/immut"
 	"github.com/eobrain/immut/list"
@@ -684,3 +685,305 @@ func Example_ordered() {
 	// {2,4,7}
 	// {10,5,7}
 }
+
+func ExampleDiff() {
+	older := list.New("a", "b", "c", "d")
+	newer := list.New("a", "c", "d", "e")
+	d := immut.Diff(older, newer)
+	for _, e := range d.Edits {
+		fmt.Println(e.Op == immut.Insert, e.Index, e.Item)
+	}
+	fmt.Println(immut.Patch(older, d))
+	// Output:
+	// false 1 b
+	// true 3 e
+	// [a,c,d,e]
+}
+
+func ExampleDiff_vector() {
+	older := vector.New(1, 2, 3)
+	seqs := []immut.Seq{
+		vector.New(1, 2, 3),
+		vector.New(),
+		vector.New(0, 1, 2, 3),
+		vector.New(3, 2, 1),
+		vector.New(1, 9, 3, 4),
+	}
+	for _, newer := range seqs {
+		d := immut.Diff(older, newer)
+		fmt.Println(len(d.Edits), immut.Patch(older, d))
+	}
+	// Output:
+	// 0 [1,2,3]
+	// 3 []
+	// 1 [0,1,2,3]
+	// 4 [3,2,1]
+	// 3 [1,9,3,4]
+}
+
+func ExampleDiff_ordered() {
+	older := ordered.New(50, 30, 80, 10, 40, 70, 90)
+	newer := older.AddFront(60).Remove(10)
+	d := immut.Diff(older, newer)
+	fmt.Println(d.Removed, d.Added)
+	fmt.Println(immut.Patch(older, d))
+	// Output:
+	// [10] [60]
+	// {30,40,50,60,70,80,90}
+}
+
+func ExampleDiff_unordered() {
+	older := unordered.New("x", "y")
+	newer := older.AddFront("z").Remove("x")
+	d := immut.Diff(older, newer)
+	fmt.Println(d.Removed, d.Added)
+	fmt.Println(immut.Patch(older, d).Len())
+	// Output:
+	// [x] [z]
+	// 2
+}
+
+func ExampleParMap() {
+	square := func(x interface{}) interface{} { return x.(int) * x.(int) }
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	seqs := []immut.Seq{
+		list.New(items...),
+		vector.New(items...),
+		ordered.New(items...),
+		unordered.New(items...),
+	}
+	for _, xs := range seqs {
+		ys, err := immut.ParMap(context.Background(), xs, square)
+		fmt.Println(ys.Len(), ys.Contains(999*999), err)
+	}
+	ys, _ := immut.ParMap(context.Background(), vector.New(items...), square)
+	fmt.Println(ys.Get(998))
+	// Output:
+	// 1000 true <nil>
+	// 1000 true <nil>
+	// 1000 true <nil>
+	// 1000 true <nil>
+	// 996004 true
+}
+
+func ExampleParFilter() {
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	isOdd := func(x interface{}) bool { return x.(int)%2 == 1 }
+	odds, _ := immut.ParFilter(context.Background(), list.New(items...), isOdd)
+	fmt.Println(odds.Len(), odds.Front(), odds.Back())
+	// Output:
+	// 500 1 999
+}
+
+func ExampleParReduce() {
+	sum := func(a, b interface{}) interface{} { return a.(int) + b.(int) }
+	total, err := immut.ParReduce(context.Background(),
+		vector.Repeat(100000, 3), 0, sum, sum)
+	fmt.Println(total, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	total, err = immut.ParReduce(ctx, vector.Repeat(100000, 3), 0, sum, sum)
+	fmt.Println(total, err)
+	// Output:
+	// 300000 <nil>
+	// <nil> context canceled
+}
+
+func ExampleDoContext() {
+	seqs := []immut.Seq{
+		list.New(1, 2, 3, 4),
+		vector.New(1, 2, 3, 4),
+		ordered.New(4, 3, 2, 1),
+	}
+	for _, xs := range seqs {
+		err := immut.DoContext(context.Background(), xs, func(x interface{}) error {
+			if x.(int) > 2 {
+				return fmt.Errorf("too big: %v", x)
+			}
+			fmt.Print(x, " ")
+			return nil
+		})
+		fmt.Println(err)
+	}
+	// Output:
+	// 1 2 too big: 3
+	// 1 2 too big: 3
+	// 1 2 too big: 3
+}
+
+func ExampleStream() {
+	ctx := context.Background()
+	squares := make(chan interface{})
+	go func() {
+		defer close(squares)
+		for x := range immut.Stream(ctx, ordered.New(3, 1, 2)) {
+			squares <- x.(int) * x.(int)
+		}
+	}()
+	xs, err := vector.FromChan(ctx, squares)
+	fmt.Println(xs, err)
+	// Output:
+	// [1,4,9] <nil>
+}
+
+func ExampleStream_cancel() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := immut.Stream(ctx, list.Repeat(1000000, "x"))
+	fmt.Println(<-ch)
+	cancel()
+	_, err := list.FromChan(ctx, make(chan interface{}))
+	fmt.Println(err)
+	// Output:
+	// x
+	// context canceled
+}
+
+func ExampleGetIn() {
+	config := unordered.NewMap(
+		"server", unordered.NewMap(
+			"ports", vector.New(80, 443)))
+	fmt.Println(immut.GetIn(config, "server", "ports", 1))
+	fmt.Println(immut.GetIn(config, "server", "hosts", 0))
+	// Output:
+	// 443 <nil>
+	// <nil> immut: no hosts in path [server hosts 0]
+}
+
+func ExampleAssocIn() {
+	ports := vector.New(80, 443)
+	config := unordered.NewMap("server", unordered.NewMap("ports", ports))
+
+	edited, _ := immut.AssocIn(config, []interface{}{"server", "ports", 1}, 8443)
+	fmt.Println(immut.GetIn(edited, "server", "ports"))
+	edited, _ = immut.AssocIn(edited, []interface{}{"server", "ports", 2}, 9000)
+	fmt.Println(immut.GetIn(edited, "server", "ports"))
+	fmt.Println(immut.GetIn(config, "server", "ports")) // unchanged
+
+	_, err := immut.AssocIn(config, []interface{}{"server", "ports", 5}, 1)
+	fmt.Println(err)
+
+	// the items of a set have no positions to edit
+	hosts := unordered.NewMap("hosts", unordered.New("a.example", "b.example"))
+	_, err = immut.AssocIn(hosts, []interface{}{"hosts", 0}, "c.example")
+	fmt.Println(err)
+	// Output:
+	// [80,8443] <nil>
+	// [80,8443,9000] <nil>
+	// [80,443] <nil>
+	// immut: no 5 in path [server ports 5]
+	// immut: no 0 in path [hosts 0]
+}
+
+func ExampleUpdateIn() {
+	order := list.New(
+		unordered.NewMap("item", "pen", "price", 2),
+		unordered.NewMap("item", "ink", "price", 5))
+	double := func(x interface{}) interface{} { return 2 * x.(int) }
+	updated, _ := immut.UpdateIn(order, []interface{}{1, "price"}, double)
+	fmt.Println(immut.GetIn(updated, 1, "price"))
+	fmt.Println(immut.GetIn(order, 1, "price"))
+	// Output:
+	// 10 <nil>
+	// 5 <nil>
+}
+
+func ExampleDissocIn() {
+	tree := list.New("a", list.New("b", "c", "d"), "e")
+	fmt.Println(immut.DissocIn(tree, []interface{}{1, 0}))
+	fmt.Println(immut.DissocIn(tree, []interface{}{1, 3}))
+	// Output:
+	// [a,[c,d],e] <nil>
+	// <nil> immut: no 3 in path [1 3]
+}
+
+func ExampleTryFront() {
+	seqs := []immut.Seq{
+		list.New(),
+		vector.New(1, 2).Filter(func(x interface{}) bool { return false }),
+		ordered.New(1).Rest(),
+		unordered.New(1).Remove(1),
+		list.New(1, 2),
+	}
+	for _, xs := range seqs {
+		front, err := immut.TryFront(xs)
+		if err == immut.ErrEmpty {
+			fmt.Println("empty")
+		} else {
+			fmt.Println(front)
+		}
+	}
+	// Output:
+	// empty
+	// empty
+	// empty
+	// empty
+	// 1
+}
+
+func ExampleTryRest() {
+	rest, err := immut.TryRest(vector.New(1))
+	fmt.Println(rest, err)
+	rest, err = immut.TryRest(rest)
+	fmt.Println(rest, err)
+	back, err := immut.TryBack(ordered.New())
+	fmt.Println(back, err)
+	// Output:
+	// [] <nil>
+	// <nil> immut: empty seq
+	// <nil> immut: empty seq
+}
+
+func ExampleTryGet() {
+	_, err := immut.TryGet(list.New("a", "b"), 2)
+	if e, ok := err.(*immut.IndexError); ok {
+		fmt.Println(e.Index, e.Len)
+	}
+	fmt.Println(err)
+	fmt.Println(immut.TryGet(list.New("a", "b"), 1))
+	// Output:
+	// 2 2
+	// immut: index 2 out of range for seq of length 2
+	// b <nil>
+}
+
+func Example_longList() {
+	long := list.Repeat(1000000, 1).AddFront(0)
+	fmt.Println(long.Len(), long.Reverse().Back(), long.AddBack(2).Back())
+
+	// Map and Filter share the unchanged end of the list
+	mapped := long.Map(func(x interface{}) interface{} { return 1 })
+	fmt.Println(mapped.Rest() == long.Rest())
+	filtered := long.Filter(func(x interface{}) bool { return x.(int) > 0 })
+	fmt.Println(filtered == long.Rest())
+
+	count := 0
+	long.DoBackwards(func(interface{}) { count++ })
+	fmt.Println(count, long.Contains(2))
+	// Output:
+	// 1000001 0 2
+	// true
+	// true
+	// 1000001 false
+}
+
+func ExampleIdentical() {
+	get := func(n int) func() int { return func() int { return n } }
+	xs := list.New(get(1), get(2))
+	ys := xs.Map(func(x interface{}) interface{} { return get(x.(func() int)() * 10) })
+
+	fmt.Println(immut.Identical([]int{1}, []int{1}), immut.Identical(xs, xs))
+	fmt.Println(immut.Identical(get(1), get(1)))
+	ys.Do(func(x interface{}) { fmt.Println(x.(func() int)()) })
+	// Output:
+	// false true
+	// false
+	// 10
+	// 20
+}
@@ -0,0 +1,52 @@
+package graph_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/graph"
+)
+
+func Example() {
+	build := graph.New().
+		AddEdge("compile", "link").
+		AddEdge("fetch", "compile").
+		AddEdge("generate", "compile").
+		AddEdge("link", "package").
+		AddEdge("fetch", "generate")
+
+	order, err := build.TopologicalSort()
+	fmt.Println(order, err)
+	fmt.Println(build.Successors("fetch"), build.Predecessors("compile"))
+	fmt.Println(build.BFS("fetch"))
+	fmt.Println(build.DFS("fetch"))
+	fmt.Println(build.ShortestPath("fetch", "package"))
+	// Output:
+	// [fetch,generate,compile,link,package] <nil>
+	// {compile,generate} {fetch,generate}
+	// [fetch,compile,generate,link,package]
+	// [fetch,compile,link,package,generate]
+	// [fetch,compile,link,package] true
+}
+
+func ExampleGraph_FindCycle() {
+	g := graph.New().AddEdge(1, 2).AddEdge(2, 3).AddEdge(3, 4)
+	cyclic := g.AddEdge(4, 2)
+
+	fmt.Println(g.FindCycle())
+	fmt.Println(cyclic.FindCycle())
+	_, err := cyclic.TopologicalSort()
+	fmt.Println(err)
+	// Output:
+	// [] false
+	// [2,3,4,2] true
+	// graph: cycle 2 -> 3 -> 4 -> 2
+}
+
+func ExampleGraph_RemoveNode() {
+	v1 := graph.New().AddEdge("a", "b").AddEdge("b", "c").AddEdge("c", "a")
+	v2 := v1.RemoveNode("b")
+	fmt.Println(v1, v1.Len(), v1.EdgeCount())
+	fmt.Println(v2, v2.Len(), v2.EdgeCount())
+	// Output:
+	// {a->{b},b->{c},c->{a}} 3 3
+	// {a->{},c->{a}} 2 1
+}
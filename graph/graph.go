@@ -0,0 +1,155 @@
+// The graph package provides persistent directed graphs, with the
+// successors and predecessors of each node kept in persistent maps, so
+// that an edit shares almost everything with the graph it was made
+// from and old versions stay valid.  Nodes can be any comparable
+// values.
+package graph
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/ordered"
+	"github.com/eobrain/immut/unordered"
+)
+
+// Create a new empty graph.
+func New() *Graph {
+	empty := unordered.NewHashedMap(nil)
+	return &Graph{ordered.New(), empty, empty, 0}
+}
+
+// A Graph is a set of nodes and of directed edges between them, with at
+// most one edge from one node to another.
+type Graph struct {
+	nodes immut.Seq // ordered set of all the nodes
+	succ  immut.Map // from nodes to the non-empty ordered sets of their successors
+	pred  immut.Map // from nodes to the non-empty ordered sets of their predecessors
+	edges int
+}
+
+// AddNode returns the graph with the node added, or the graph itself if
+// it is already there. O(log n)
+func (g *Graph) AddNode(x interface{}) *Graph {
+	nodes := g.nodes.AddFront(x)
+	if nodes == g.nodes {
+		return g
+	}
+	return &Graph{nodes, g.succ, g.pred, g.edges}
+}
+
+// AddEdge returns the graph with an edge from one node to another,
+// adding the nodes if they are not already there. O(log n)
+func (g *Graph) AddEdge(from, to interface{}) *Graph {
+	g = g.AddNode(from).AddNode(to)
+	if g.HasEdge(from, to) {
+		return g
+	}
+	return &Graph{
+		g.nodes,
+		g.succ.Assoc(from, g.Successors(from).AddFront(to)),
+		g.pred.Assoc(to, g.Predecessors(to).AddFront(from)),
+		g.edges + 1,
+	}
+}
+
+// RemoveEdge returns the graph without the edge from one node to
+// another, or the graph itself if there is no such edge. O(log n)
+func (g *Graph) RemoveEdge(from, to interface{}) *Graph {
+	if !g.HasEdge(from, to) {
+		return g
+	}
+	return &Graph{
+		g.nodes,
+		put(g.succ, from, g.Successors(from).Remove(to)),
+		put(g.pred, to, g.Predecessors(to).Remove(from)),
+		g.edges - 1,
+	}
+}
+
+// RemoveNode returns the graph without the node and its edges, or the
+// graph itself if the node is not there. O(d*log n) for a node with d
+// edges
+func (g *Graph) RemoveNode(x interface{}) *Graph {
+	if !g.HasNode(x) {
+		return g
+	}
+	g = g.RemoveEdge(x, x)
+	succ, pred := g.succ, g.pred
+	g.Successors(x).Do(func(y interface{}) {
+		pred = put(pred, y, get(pred, y).Remove(x))
+	})
+	g.Predecessors(x).Do(func(y interface{}) {
+		succ = put(succ, y, get(succ, y).Remove(x))
+	})
+	edges := g.edges - g.Successors(x).Len() - g.Predecessors(x).Len()
+	return &Graph{g.nodes.Remove(x), succ.Dissoc(x), pred.Dissoc(x), edges}
+}
+
+// HasNode is whether the node is in the graph. O(log n)
+func (g *Graph) HasNode(x interface{}) bool { return g.nodes.Contains(x) }
+
+// HasEdge is whether there is an edge from one node to another.
+// O(log n)
+func (g *Graph) HasEdge(from, to interface{}) bool { return g.Successors(from).Contains(to) }
+
+// Nodes returns all the nodes, as an ordered set. O(1)
+func (g *Graph) Nodes() immut.Seq { return g.nodes }
+
+// Successors returns the nodes with edges from x, as an ordered set,
+// which is empty if x is not in the graph. O(log n)
+func (g *Graph) Successors(x interface{}) immut.Seq { return get(g.succ, x) }
+
+// Predecessors returns the nodes with edges to x, as an ordered set,
+// which is empty if x is not in the graph. O(log n)
+func (g *Graph) Predecessors(x interface{}) immut.Seq { return get(g.pred, x) }
+
+// Len is the number of nodes. O(1)
+func (g *Graph) Len() int { return g.nodes.Len() }
+
+// EdgeCount is the number of edges. O(1)
+func (g *Graph) EdgeCount() int { return g.edges }
+
+func (g *Graph) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	sep := ""
+	g.nodes.Do(func(x interface{}) {
+		fmt.Fprintf(&buf, "%s%v->%v", sep, x, g.Successors(x))
+		sep = ","
+	})
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// Everything below here is private
+
+func get(m immut.Map, x interface{}) immut.Seq {
+	if xs, ok := m.Get(x); ok {
+		return xs.(immut.Seq)
+	}
+	return ordered.New()
+}
+
+// Return the map with x associated with the set, or without x if the
+// set is empty
+func put(m immut.Map, x interface{}, xs immut.Seq) immut.Map {
+	if xs.IsEmpty() {
+		return m.Dissoc(x)
+	}
+	return m.Assoc(x, xs)
+}
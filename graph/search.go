@@ -0,0 +1,181 @@
+package graph
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/vector"
+	"strings"
+)
+
+// A CycleError is returned when a graph with a cycle has no topological
+// order.
+type CycleError struct {
+	Cycle immut.Seq // the nodes on the cycle, starting and ending at the same one
+}
+
+func (e *CycleError) Error() string {
+	var path []string
+	e.Cycle.Do(func(x interface{}) { path = append(path, fmt.Sprint(x)) })
+	return "graph: cycle " + strings.Join(path, " -> ")
+}
+
+// BFS returns the nodes reachable from start in breadth-first order,
+// visiting the successors of each node in their order, as a vector.
+// O(n+e)
+func (g *Graph) BFS(start interface{}) immut.Seq {
+	if !g.HasNode(start) {
+		return vector.New()
+	}
+	order := []interface{}{start}
+	seen := map[interface{}]bool{start: true}
+	for i := 0; i < len(order); i++ {
+		g.Successors(order[i]).Do(func(y interface{}) {
+			if !seen[y] {
+				seen[y] = true
+				order = append(order, y)
+			}
+		})
+	}
+	return vector.New(order...)
+}
+
+// DFS returns the nodes reachable from start in depth-first preorder,
+// visiting the successors of each node in their order, as a vector.
+// O(n+e)
+func (g *Graph) DFS(start interface{}) immut.Seq {
+	if !g.HasNode(start) {
+		return vector.New()
+	}
+	var order []interface{}
+	seen := map[interface{}]bool{}
+	stack := []interface{}{start}
+	for len(stack) > 0 {
+		x := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[x] {
+			continue
+		}
+		seen[x] = true
+		order = append(order, x)
+		// push in reverse so that the first successor is visited first
+		g.Successors(x).DoBackwards(func(y interface{}) {
+			if !seen[y] {
+				stack = append(stack, y)
+			}
+		})
+	}
+	return vector.New(order...)
+}
+
+// TopologicalSort returns the nodes in an order where every edge goes
+// from an earlier node to a later one, as a vector, or a *CycleError if
+// there is no such order.  Of the nodes that could come next, the one
+// that became ready first comes first. O(n+e)
+func (g *Graph) TopologicalSort() (immut.Seq, error) {
+	inDegree := make(map[interface{}]int, g.Len())
+	var order []interface{}
+	g.nodes.Do(func(x interface{}) {
+		inDegree[x] = g.Predecessors(x).Len()
+		if inDegree[x] == 0 {
+			order = append(order, x)
+		}
+	})
+	for i := 0; i < len(order); i++ {
+		g.Successors(order[i]).Do(func(y interface{}) {
+			inDegree[y]--
+			if inDegree[y] == 0 {
+				order = append(order, y)
+			}
+		})
+	}
+	if len(order) < g.Len() {
+		cycle, _ := g.FindCycle()
+		return nil, &CycleError{cycle}
+	}
+	return vector.New(order...), nil
+}
+
+// FindCycle returns the nodes on some cycle, starting and ending at the
+// same node, and whether there is any cycle at all. O(n+e)
+func (g *Graph) FindCycle() (immut.Seq, bool) {
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[interface{}]int, g.Len())
+	var path []interface{}
+	var cycle []interface{}
+	var visit func(x interface{}) bool
+	visit = func(x interface{}) bool {
+		state[x] = onPath
+		path = append(path, x)
+		found := !g.Successors(x).Forall(func(y interface{}) bool {
+			switch state[y] {
+			case onPath:
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == y {
+						cycle = append(append(cycle, path[i:]...), y)
+						return false
+					}
+				}
+			case unvisited:
+				return !visit(y)
+			}
+			return true
+		})
+		path = path[:len(path)-1]
+		state[x] = done
+		return found
+	}
+	found := !g.nodes.Forall(func(x interface{}) bool {
+		return state[x] != unvisited || !visit(x)
+	})
+	return vector.New(cycle...), found
+}
+
+// ShortestPath returns the nodes on a path with the fewest edges from
+// one node to another, including both ends, and whether there is any
+// path. O(n+e)
+func (g *Graph) ShortestPath(from, to interface{}) (immut.Seq, bool) {
+	if !g.HasNode(from) || !g.HasNode(to) {
+		return vector.New(), false
+	}
+	parent := map[interface{}]interface{}{from: nil}
+	queue := []interface{}{from}
+	for i := 0; i < len(queue); i++ {
+		x := queue[i]
+		if x == to {
+			var path []interface{}
+			for ; x != from; x = parent[x] {
+				path = append(path, x)
+			}
+			path = append(path, from)
+			for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+				path[l], path[r] = path[r], path[l]
+			}
+			return vector.New(path...), true
+		}
+		g.Successors(x).Do(func(y interface{}) {
+			if _, seen := parent[y]; !seen {
+				parent[y] = x
+				queue = append(queue, y)
+			}
+		})
+	}
+	return vector.New(), false
+}
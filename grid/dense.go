@@ -0,0 +1,226 @@
+package grid
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+)
+
+// New returns a dense grid of the given size with every cell nil.  Its
+// cells are kept in 8x8 tiles at the leaves of a trie with 32 children
+// per node, so getting or setting a cell is O(log n) and a new grid
+// shares all the tiles but one with the grid it was made from.
+func New(rows, cols int) Grid {
+	if rows < 0 || cols < 0 {
+		panic(&immut.IndexError{Index: min(rows, cols), Len: 0})
+	}
+	tileCols := (cols + tileSize - 1) >> tileBits
+	tileRows := (rows + tileSize - 1) >> tileBits
+	depth := 1
+	for n := trieWidth; n < tileRows*tileCols; n <<= trieBits {
+		depth++
+	}
+	return &Dense{rows, cols, tileCols, depth, nil}
+}
+
+// Dense is a grid that stores every cell
+type Dense struct {
+	rows, cols int
+	tileCols   int
+	depth      int // levels of branches above the tiles
+	root       *branch
+}
+
+// O(1)
+func (g *Dense) Rows() int { return g.rows }
+
+// O(1)
+func (g *Dense) Cols() int { return g.cols }
+
+// O(log n)
+func (g *Dense) At(r, c int) interface{} {
+	checkCell(g, r, c)
+	t := g.tile(r, c)
+	if t == nil {
+		return nil
+	}
+	return t[cell(r, c)]
+}
+
+// O(log n), copying one tile and the branches above it
+func (g *Dense) Set(r, c int, v interface{}) Grid {
+	checkCell(g, r, c)
+	if immut.Identical(g.At(r, c), v) {
+		return g
+	}
+	root := g.root.set(g.depth, g.tileIndex(r, c), cell(r, c), v)
+	return &Dense{g.rows, g.cols, g.tileCols, g.depth, root}
+}
+
+// O(cols)
+func (g *Dense) Row(r int) immut.Seq {
+	checkIndex(r, g.rows)
+	return collect(g, r, 0, r+1, g.cols, g.cols)
+}
+
+// O(rows)
+func (g *Dense) Col(c int) immut.Seq {
+	checkIndex(c, g.cols)
+	return collect(g, 0, c, g.rows, c+1, g.rows)
+}
+
+// O(1)
+func (g *Dense) Region(r0, c0, r1, c1 int) Grid { return newRegion(g, r0, c0, r1, c1) }
+
+// O(n)
+func (g *Dense) Map(f func(r, c int, v interface{}) interface{}) Grid {
+	result := &Dense{g.rows, g.cols, g.tileCols, g.depth, nil}
+	tileRows := (g.rows + tileSize - 1) >> tileBits
+	tiles := make([]*tile, tileRows*g.tileCols)
+	for i := range tiles {
+		r0, c0 := i/g.tileCols<<tileBits, i%g.tileCols<<tileBits
+		var old, t tile
+		if src := g.tile(r0, c0); src != nil {
+			old = *src
+		}
+		empty := true
+		for r := r0; r < min(r0+tileSize, g.rows); r++ {
+			for c := c0; c < min(c0+tileSize, g.cols); c++ {
+				j := cell(r, c)
+				if t[j] = f(r, c, old[j]); t[j] != nil {
+					empty = false
+				}
+			}
+		}
+		if !empty {
+			tiles[i] = &t
+		}
+	}
+	result.root = build(g.depth, tiles)
+	return result
+}
+
+// O(n)
+func (g *Dense) Do(f func(r, c int, v interface{})) {
+	g.doRect(0, 0, g.rows, g.cols, f)
+}
+
+func (g *Dense) String() string { return format(g) }
+
+// Everything below here is private
+
+const (
+	tileBits  = 3
+	tileSize  = 1 << tileBits
+	trieBits  = 5
+	trieWidth = 1 << trieBits
+)
+
+// The cells of a square of the grid, in row order
+type tile [tileSize * tileSize]interface{}
+
+// An interior node of the trie.  The children of the bottom level are
+// tiles and of the other levels are branches, and either may be nil if
+// every cell under it is nil.
+type branch [trieWidth]interface{}
+
+// Position of a cell within its tile
+func cell(r, c int) int { return (r&(tileSize-1))<<tileBits | c&(tileSize-1) }
+
+func (g *Dense) tileIndex(r, c int) int { return r>>tileBits*g.tileCols + c>>tileBits }
+
+func (g *Dense) tile(r, c int) *tile {
+	i := g.tileIndex(r, c)
+	b := g.root
+	for level := g.depth - 1; b != nil; level-- {
+		child := b[i>>(level*trieBits)&(trieWidth-1)]
+		if level == 0 {
+			t, _ := child.(*tile)
+			return t
+		}
+		b, _ = child.(*branch)
+	}
+	return nil
+}
+
+// Return a copy of the trie under b, which has the given depth, with one
+// cell of the tile at index i set
+func (b *branch) set(depth, i, j int, v interface{}) *branch {
+	var result branch
+	if b != nil {
+		result = *b
+	}
+	k := i >> ((depth - 1) * trieBits) & (trieWidth - 1)
+	if depth == 1 {
+		var t tile
+		if old, ok := result[k].(*tile); ok {
+			t = *old
+		}
+		t[j] = v
+		result[k] = &t
+	} else {
+		child, _ := result[k].(*branch)
+		result[k] = child.set(depth-1, i, j, v)
+	}
+	return &result
+}
+
+// Return a trie of the given depth with the tiles at its leaves
+func build(depth int, tiles []*tile) *branch {
+	level := make([]interface{}, len(tiles))
+	for i, t := range tiles {
+		if t != nil {
+			level[i] = t
+		}
+	}
+	for ; depth > 0; depth-- {
+		parents := make([]interface{}, (len(level)+trieWidth-1)/trieWidth)
+		for i := range parents {
+			var b branch
+			empty := true
+			for j := range b {
+				if k := i*trieWidth + j; k < len(level) && level[k] != nil {
+					b[j] = level[k]
+					empty = false
+				}
+			}
+			if !empty {
+				parents[i] = &b
+			}
+		}
+		level = parents
+	}
+	if len(level) == 0 || level[0] == nil {
+		return nil
+	}
+	return level[0].(*branch)
+}
+
+// Visit the cells of a rectangle a tile at a time
+func (g *Dense) doRect(r0, c0, r1, c1 int, f func(r, c int, v interface{})) {
+	for r := r0; r < r1; r++ {
+		for c := c0; c < c1; {
+			end := min(c1, (c|(tileSize-1))+1)
+			t := g.tile(r, c)
+			for ; c < end; c++ {
+				if t == nil {
+					f(r, c, nil)
+				} else {
+					f(r, c, t[cell(r, c)])
+				}
+			}
+		}
+	}
+}
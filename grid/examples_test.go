@@ -0,0 +1,52 @@
+package grid_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/grid"
+)
+
+func Example() {
+	board := grid.New(3, 3)
+	moves := []grid.Grid{board}
+	board = board.Set(1, 1, "X")
+	moves = append(moves, board)
+	board = board.Set(0, 2, "O")
+
+	fmt.Print(board)
+	fmt.Println(moves[1].At(0, 2), board.At(0, 2))
+	fmt.Println(board.Col(2))
+	// Output:
+	// [<nil>,<nil>,O]
+	// [<nil>,X,<nil>]
+	// [<nil>,<nil>,<nil>]
+	// <nil> O
+	// [O,<nil>,<nil>]
+}
+
+func ExampleNewSparse() {
+	sheet := grid.NewSparse(1000000, 16384).
+		Set(0, 0, "Total").
+		Set(0, 1, 42).
+		Set(999999, 16383, "last")
+
+	fmt.Println(sheet.(*grid.Sparse).Count(), sheet.At(999999, 16383))
+	sheet.Do(func(r, c int, v interface{}) { fmt.Println(r, c, v) })
+	// Output:
+	// 3 last
+	// 0 0 Total
+	// 0 1 42
+	// 999999 16383 last
+}
+
+func ExampleDense_Region() {
+	g := grid.New(4, 5).Map(func(r, c int, _ interface{}) interface{} {
+		return 10*r + c
+	})
+	view := g.Region(1, 1, 3, 4)
+	fmt.Print(view)
+	fmt.Println(view.Row(1), view.Set(0, 0, "x").At(0, 0), g.At(1, 1))
+	// Output:
+	// [11,12,13]
+	// [21,22,23]
+	// [21,22,23] x 11
+}
@@ -0,0 +1,178 @@
+// The grid package provides persistent two-dimensional grids of values.
+// A dense grid keeps its cells in square tiles at the leaves of a trie,
+// and a sparse grid keeps only the cells that are set in int maps.
+// Either way setting a cell makes a new grid in O(log n), sharing
+// everything the edit does not touch with the old one.
+package grid
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/vector"
+)
+
+// A Grid is an immutable rectangle of cells, indexed by row and column
+// from 0.  Cells that have not been set are nil.
+type Grid interface {
+
+	// Rows is the number of rows.
+	Rows() int
+
+	// Cols is the number of columns.
+	Cols() int
+
+	// At returns the value of a cell, panicking with an
+	// *immut.IndexError if it is out of range.
+	At(r, c int) interface{}
+
+	// Set returns a new grid with the cell set to the value.
+	Set(r, c int, v interface{}) Grid
+
+	// Row returns the values of a row in column order, as a vector.
+	Row(r int) immut.Seq
+
+	// Col returns the values of a column in row order, as a vector.
+	Col(c int) immut.Seq
+
+	// Region returns a view of the rows from r0 up to but not
+	// including r1 and the columns from c0 up to but not including c1,
+	// sharing the cells of this grid.
+	Region(r0, c0, r1, c1 int) Grid
+
+	// Map returns a new grid of the same size and kind with each cell
+	// set to the result of calling f with its row, column and value.
+	// A sparse grid only calls f for the cells that are set.
+	Map(f func(r, c int, v interface{}) interface{}) Grid
+
+	// Do calls f with the row, column and value of each cell in row
+	// order.  A sparse grid only calls f for the cells that are set.
+	Do(f func(r, c int, v interface{}))
+}
+
+// Everything below here is private
+
+func checkIndex(i, n int) {
+	if i < 0 || i >= n {
+		panic(&immut.IndexError{Index: i, Len: n})
+	}
+}
+
+func checkCell(g Grid, r, c int) {
+	checkIndex(r, g.Rows())
+	checkIndex(c, g.Cols())
+}
+
+func checkRegion(g Grid, r0, c0, r1, c1 int) {
+	if r0 < 0 || r0 > r1 || r1 > g.Rows() {
+		panic(&immut.IndexError{Index: r1, Len: g.Rows()})
+	}
+	if c0 < 0 || c0 > c1 || c1 > g.Cols() {
+		panic(&immut.IndexError{Index: c1, Len: g.Cols()})
+	}
+}
+
+// The grids that regions can be views of
+type rect interface {
+	Grid
+
+	// Call f for the cells in rows r0 up to r1 and columns c0 up to c1
+	doRect(r0, c0, r1, c1 int, f func(r, c int, v interface{}))
+}
+
+// Return a vector of the n cells in a rectangle one row or column thick
+func collect(g Grid, r0, c0, r1, c1, n int) immut.Seq {
+	items := make([]interface{}, n)
+	g.(rect).doRect(r0, c0, r1, c1, func(r, c int, v interface{}) {
+		items[r-r0+c-c0] = v
+	})
+	return vector.New(items...)
+}
+
+// A view of part of another grid
+type region struct {
+	base       Grid
+	r0, c0     int
+	rows, cols int
+}
+
+func newRegion(base Grid, r0, c0, r1, c1 int) Grid {
+	checkRegion(base, r0, c0, r1, c1)
+	if b, ok := base.(*region); ok {
+		// a view of a view is a view of the original
+		return &region{b.base, b.r0 + r0, b.c0 + c0, r1 - r0, c1 - c0}
+	}
+	return &region{base, r0, c0, r1 - r0, c1 - c0}
+}
+
+func (g *region) Rows() int { return g.rows }
+func (g *region) Cols() int { return g.cols }
+
+func (g *region) At(r, c int) interface{} {
+	checkCell(g, r, c)
+	return g.base.At(g.r0+r, g.c0+c)
+}
+
+// The new region is a view of the base grid with the cell set
+func (g *region) Set(r, c int, v interface{}) Grid {
+	checkCell(g, r, c)
+	return &region{g.base.Set(g.r0+r, g.c0+c, v), g.r0, g.c0, g.rows, g.cols}
+}
+
+func (g *region) Row(r int) immut.Seq {
+	checkIndex(r, g.rows)
+	return collect(g.base, g.r0+r, g.c0, g.r0+r+1, g.c0+g.cols, g.cols)
+}
+
+func (g *region) Col(c int) immut.Seq {
+	checkIndex(c, g.cols)
+	return collect(g.base, g.r0, g.c0+c, g.r0+g.rows, g.c0+c+1, g.rows)
+}
+
+func (g *region) Region(r0, c0, r1, c1 int) Grid { return newRegion(g, r0, c0, r1, c1) }
+
+// The new grid is a copy of the region, not a view
+func (g *region) Map(f func(r, c int, v interface{}) interface{}) Grid {
+	var result Grid
+	if _, ok := g.base.(*Sparse); ok {
+		result = NewSparse(g.rows, g.cols)
+	} else {
+		result = New(g.rows, g.cols)
+	}
+	g.Do(func(r, c int, v interface{}) {
+		if v = f(r, c, v); v != nil {
+			result = result.Set(r, c, v)
+		}
+	})
+	return result
+}
+
+func (g *region) Do(f func(r, c int, v interface{})) {
+	g.base.(rect).doRect(g.r0, g.c0, g.r0+g.rows, g.c0+g.cols,
+		func(r, c int, v interface{}) { f(r-g.r0, c-g.c0, v) })
+}
+
+func (g *region) String() string { return format(g) }
+
+// Format a grid with one line per row
+func format(g Grid) string {
+	var buf bytes.Buffer
+	for r := 0; r < g.Rows(); r++ {
+		fmt.Fprintln(&buf, g.Row(r))
+	}
+	return buf.String()
+}
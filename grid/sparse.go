@@ -0,0 +1,125 @@
+package grid
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/intmap"
+)
+
+// NewSparse returns a sparse grid of the given size with every cell nil.
+// Only the cells that are set take any space.  They are kept in two int
+// maps, one in row order and one in column order, so that getting or
+// setting a cell is O(log n) and both rows and columns can be found
+// without looking at the rest of the grid.
+func NewSparse(rows, cols int) Grid {
+	if rows < 0 || cols < 0 {
+		panic(&immut.IndexError{Index: min(rows, cols), Len: 0})
+	}
+	return &Sparse{rows, cols, &intmap.Map{}, &intmap.Map{}}
+}
+
+// Sparse is a grid that stores only the cells that are not nil
+type Sparse struct {
+	rows, cols int
+	byRow      *intmap.Map // keyed by r*cols+c
+	byCol      *intmap.Map // keyed by c*rows+r
+}
+
+// O(1)
+func (g *Sparse) Rows() int { return g.rows }
+
+// O(1)
+func (g *Sparse) Cols() int { return g.cols }
+
+// Count returns the number of cells that are not nil. O(1)
+func (g *Sparse) Count() int { return g.byRow.Len() }
+
+// O(log n)
+func (g *Sparse) At(r, c int) interface{} {
+	checkCell(g, r, c)
+	v, _ := g.byRow.Get(r*g.cols + c)
+	return v
+}
+
+// O(log n), where setting a cell to nil removes it
+func (g *Sparse) Set(r, c int, v interface{}) Grid {
+	checkCell(g, r, c)
+	if immut.Identical(g.At(r, c), v) {
+		return g
+	}
+	if v == nil {
+		return &Sparse{g.rows, g.cols,
+			g.byRow.Dissoc(r*g.cols + c).(*intmap.Map),
+			g.byCol.Dissoc(c*g.rows + r).(*intmap.Map)}
+	}
+	return &Sparse{g.rows, g.cols,
+		g.byRow.Assoc(r*g.cols+c, v).(*intmap.Map),
+		g.byCol.Assoc(c*g.rows+r, v).(*intmap.Map)}
+}
+
+// O(cols + log n)
+func (g *Sparse) Row(r int) immut.Seq {
+	checkIndex(r, g.rows)
+	return collect(g, r, 0, r+1, g.cols, g.cols)
+}
+
+// O(rows + log n)
+func (g *Sparse) Col(c int) immut.Seq {
+	checkIndex(c, g.cols)
+	return collect(g, 0, c, g.rows, c+1, g.rows)
+}
+
+// O(1)
+func (g *Sparse) Region(r0, c0, r1, c1 int) Grid { return newRegion(g, r0, c0, r1, c1) }
+
+// O(n log n) for the n cells that are set, removing any that f maps to
+// nil
+func (g *Sparse) Map(f func(r, c int, v interface{}) interface{}) Grid {
+	var result Grid = NewSparse(g.rows, g.cols)
+	g.Do(func(r, c int, v interface{}) {
+		result = result.Set(r, c, f(r, c, v))
+	})
+	return result
+}
+
+// O(n) for the n cells that are set
+func (g *Sparse) Do(f func(r, c int, v interface{})) {
+	g.byRow.Do(func(k, v interface{}) {
+		f(k.(int)/g.cols, k.(int)%g.cols, v)
+	})
+}
+
+func (g *Sparse) String() string { return format(g) }
+
+// Everything below here is private
+
+// Visit the cells of a rectangle that are set in row order, a row at a
+// time unless it is a single column
+func (g *Sparse) doRect(r0, c0, r1, c1 int, f func(r, c int, v interface{})) {
+	if c1-c0 != 1 {
+		for r := r0; r < r1; r++ {
+			g.byRow.Range(r*g.cols+c0, r*g.cols+c1).Do(func(k, v interface{}) {
+				f(r, k.(int)-r*g.cols, v)
+			})
+		}
+		return
+	}
+	for c := c0; c < c1; c++ {
+		g.byCol.Range(c*g.rows+r0, c*g.rows+r1).Do(func(k, v interface{}) {
+			f(k.(int)-c*g.rows, c, v)
+		})
+	}
+}
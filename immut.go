@@ -1,10 +1,28 @@
 // The immut package contains immutable structure-sharing collections
 // for Go in the style of Scala or Clojure.
+//
+// # Concurrency
+//
+// Every Seq in this module, and every version derived from it, is safe
+// for concurrent use by any number of goroutines without further
+// synchronization.  A Seq is never modified once it has been returned to
+// the caller, so goroutines can read it, iterate over it, and derive new
+// versions from it while other goroutines do the same, even though the
+// versions share structure.  Implementations must keep this contract: a
+// node may not be changed after it is created, and any value computed
+// lazily and cached in a node must be published with sync/atomic or
+// sync.Once.
+//
+// The functions passed to methods such as Do, Map and Filter are called
+// on the calling goroutine.  The items themselves are the caller's
+// concern: a Seq of pointers does not make what they point to safe to
+// share.
 package immut
 
 import (
 	"bytes"
 	"io"
+	"reflect"
 )
 
 // Copyright 2013 Eamonn O'Brien-Strain
@@ -90,6 +108,41 @@ type Seq interface {
 	Items() []interface{}
 }
 
+// A Map is an immutable association from keys to values.
+type Map interface {
+
+	// Len is the number of keys.
+	Len() int
+
+	// Get returns the value associated with the key.
+	// Sets false if the key is not in the map.
+	Get(key interface{}) (interface{}, bool)
+
+	// Assoc returns a new map with the key associated with the value,
+	// replacing any value it was associated with before.
+	Assoc(key, value interface{}) Map
+
+	// Dissoc returns a new map without the key, or the map itself if
+	// the key is not in it.
+	Dissoc(key interface{}) Map
+
+	// Keys returns the keys as a Seq.
+	Keys() Seq
+
+	// Apply the function to each key and its value.
+	Do(func(key, value interface{}))
+}
+
+// A Set is a Seq that keeps its items in an order of its own, such as
+// sorted or by hash, rather than in the order they were added.  Its
+// items have no positions to edit, so paths cannot index into it.
+type Set interface {
+	Seq
+
+	// IsSet does nothing but mark the Seq as a Set.
+	IsSet()
+}
+
 // Return a string formed by concatenation of the string
 // representations of the items separated by sep. O(n)
 func Join(xs Seq, sep string) string {
@@ -97,3 +150,25 @@ func Join(xs Seq, sep string) string {
 	xs.Join(sep, &buf)
 	return buf.String()
 }
+
+// Identical is whether x and y are the same item: equal if they are
+// comparable, or else the very same slice or map.  Functions are never
+// identical, as closures made by one function literal share their code
+// but not the values they capture.  Unlike == it never panics, so it is
+// safe for items such as vectors. O(1)
+func Identical(x, y interface{}) bool {
+	vx, vy := reflect.ValueOf(x), reflect.ValueOf(y)
+	if !vx.IsValid() || !vy.IsValid() || vx.Type() != vy.Type() {
+		return !vx.IsValid() && !vy.IsValid()
+	}
+	if vx.Comparable() && vy.Comparable() {
+		return x == y
+	}
+	switch vx.Kind() {
+	case reflect.Slice:
+		return vx.Len() == vy.Len() && vx.Pointer() == vy.Pointer()
+	case reflect.Map:
+		return vx.Pointer() == vy.Pointer()
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+// The gobitems package holds the encoding/gob support shared by the
+// collections, which write themselves as their items and rebuild
+// themselves from them.
+package gobitems
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/eobrain/immut"
+)
+
+// Register registers the concrete type of value with gob as "immut/"
+// followed by the name, so that the collection can be sent as an
+// interface value, for example as an immut.Seq field of a struct.
+func Register(name string, value interface{}) {
+	gob.RegisterName("immut/"+name, value)
+}
+
+// Encode writes the items.
+func Encode(items []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(items)
+	return buf.Bytes(), err
+}
+
+// EncodeMap writes the keys and values of the map, alternating.
+func EncodeMap(m immut.Map) ([]byte, error) {
+	items := make([]interface{}, 0, 2*m.Len())
+	m.Do(func(k, v interface{}) { items = append(items, k, v) })
+	return Encode(items)
+}
+
+// Decode reads the items written by Encode, or the alternating keys and
+// values written by EncodeMap.
+func Decode(data []byte) (items []interface{}, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&items)
+	return
+}
@@ -0,0 +1,98 @@
+package interval
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut/ordered"
+	"reflect"
+)
+
+// A Comparator returns a negative number if a is before b, zero if
+// they are at the same position and a positive number if a is after b.
+type Comparator func(a, b interface{}) int
+
+// Natural is the Comparator used when none is given.  Numbers of any
+// type compare by value, values with a Compare method such as time.Time
+// and netip.Addr compare with it, and anything else compares with
+// ordered.Compare, which is how ordered sets order their items.
+func Natural(a, b interface{}) int {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.IsValid() && vb.IsValid() {
+		if c, ok := compareNumbers(va, vb); ok {
+			return c
+		}
+		if m := va.MethodByName("Compare"); m.IsValid() {
+			t := m.Type()
+			if t.NumIn() == 1 && t.NumOut() == 1 && vb.Type() == t.In(0) && t.Out(0).Kind() == reflect.Int {
+				return int(m.Call([]reflect.Value{vb})[0].Int())
+			}
+		}
+	}
+	return ordered.Compare(a, b)
+}
+
+// Everything below here is private
+
+func compareNumbers(a, b reflect.Value) (int, bool) {
+	ka, kb := kind(a), kind(b)
+	switch {
+	case ka == 0 || kb == 0:
+		return 0, false
+	case ka == reflect.Int && kb == reflect.Int:
+		return sign(a.Int() < b.Int(), a.Int() > b.Int()), true
+	case ka == reflect.Uint && kb == reflect.Uint:
+		return sign(a.Uint() < b.Uint(), a.Uint() > b.Uint()), true
+	case ka == reflect.Int && kb == reflect.Uint:
+		return sign(a.Int() < 0 || uint64(a.Int()) < b.Uint(), a.Int() >= 0 && uint64(a.Int()) > b.Uint()), true
+	case ka == reflect.Uint && kb == reflect.Int:
+		c, _ := compareNumbers(b, a)
+		return -c, true
+	}
+	fa, fb := float(a), float(b)
+	return sign(fa < fb, fa > fb), true
+}
+
+// Int, Uint or Float for numbers, or 0 for anything else
+func kind(v reflect.Value) reflect.Kind {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return 0
+}
+
+func float(v reflect.Value) float64 {
+	switch kind(v) {
+	case reflect.Int:
+		return float64(v.Int())
+	case reflect.Uint:
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+func sign(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
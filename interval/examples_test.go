@@ -0,0 +1,58 @@
+package interval_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/interval"
+	"github.com/eobrain/immut/ordered"
+	"net/netip"
+	"time"
+)
+
+func Example() {
+	at := func(hour int) time.Time { return time.Date(2024, 5, 1, hour, 0, 0, 0, time.UTC) }
+	monday := interval.New(nil,
+		interval.Interval{Lo: at(9), Hi: at(10), Value: "standup"},
+		interval.Interval{Lo: at(11), Hi: at(13), Value: "review"},
+		interval.Interval{Lo: at(12), Hi: at(14), Value: "lunch"})
+	later := monday.Insert(at(15), at(16), "retro")
+
+	show := func(ivs immut.Seq) {
+		fmt.Println(ivs.Map(func(x interface{}) interface{} {
+			return x.(interval.Interval).Value
+		}))
+	}
+	show(monday.Overlapping(at(10), at(12)))
+	show(monday.Stabbing(at(12)))
+	show(later.Overlapping(at(13), at(18)))
+	fmt.Println(monday.Len(), later.Len())
+	// Output:
+	// [review]
+	// [review,lunch]
+	// [lunch,retro]
+	// 3 4
+}
+
+func ExampleTree_Stabbing() {
+	pools := interval.New(nil).
+		Insert(netip.MustParseAddr("10.0.0.0"), netip.MustParseAddr("10.0.1.0"), "office").
+		Insert(netip.MustParseAddr("10.0.0.128"), netip.MustParseAddr("10.0.0.192"), "printers").
+		Insert(netip.MustParseAddr("10.1.0.0"), netip.MustParseAddr("10.2.0.0"), "lab")
+	fmt.Println(pools.Stabbing(netip.MustParseAddr("10.0.0.130")))
+	fmt.Println(pools.Stabbing(netip.MustParseAddr("10.0.2.1")))
+	// Output:
+	// [[10.0.0.0,10.0.1.0):office,[10.0.0.128,10.0.0.192):printers]
+	// []
+}
+
+func ExampleNatural() {
+	fmt.Println(interval.Natural(9, 10), ordered.Compare(9, 10))
+	fmt.Println(interval.Natural(2.5, uint8(2)))
+
+	pages := []interval.Interval{{Lo: 10, Hi: 20, Value: "body"}, {Lo: 2, Hi: 5, Value: "intro"}}
+	fmt.Println(interval.New(nil, pages...))
+	// Output:
+	// -1 1
+	// 1
+	// {[2,5):intro,[10,20):body}
+}
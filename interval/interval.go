@@ -0,0 +1,443 @@
+// The interval package provides persistent sets of half-open intervals
+// [Lo, Hi) with payloads, kept in a balanced search tree ordered by
+// their bounds and augmented with the highest Hi in each subtree, so
+// that the intervals overlapping a range or containing a point can be
+// found without looking at the others.  Versions share all the nodes
+// that are not on the path to a change.
+package interval
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/vector"
+	"io"
+	"sort"
+)
+
+// An Interval is the half-open range from Lo up to but not including
+// Hi, together with a payload Value.  Lo must be before Hi.
+type Interval struct {
+	Lo, Hi, Value interface{}
+}
+
+func (iv Interval) String() string { return fmt.Sprintf("[%v,%v):%v", iv.Lo, iv.Hi, iv.Value) }
+
+// Create a new tree of the intervals, with bounds compared by the
+// Comparator, or by Natural if it is nil. O(n*log(n))
+func New(cmp Comparator, intervals ...Interval) *Tree {
+	if cmp == nil {
+		cmp = Natural
+	}
+	t := &Tree{cmp, nil}
+	for _, iv := range intervals {
+		t.check(iv)
+	}
+	ivs := append([]Interval(nil), intervals...)
+	sort.SliceStable(ivs, func(i, j int) bool { return t.order(ivs[i], ivs[j]) < 0 })
+	t.root = t.build(ivs)
+	return t
+}
+
+// A Seq of Interval values ordered by Lo and then Hi, which may be empty.
+// Intervals with the same bounds are kept in the order they were added.
+type Tree struct {
+	cmp  Comparator
+	root *node // nil if empty
+}
+
+// Insert returns the tree with the interval [lo, hi) added. O(log n)
+func (t *Tree) Insert(lo, hi, value interface{}) *Tree { return t.add(Interval{lo, hi, value}) }
+
+// Overlapping returns the intervals that overlap [lo, hi), in order, as
+// a vector. O(log n + k) for k intervals
+func (t *Tree) Overlapping(lo, hi interface{}) immut.Seq {
+	var ivs []interface{}
+	if t.cmp(lo, hi) < 0 {
+		t.overlapping(t.root, lo, hi, &ivs)
+	}
+	return vector.New(ivs...)
+}
+
+// Stabbing returns the intervals that contain the point, in order, as a
+// vector. O(log n + k) for k intervals
+func (t *Tree) Stabbing(point interface{}) immut.Seq {
+	var ivs []interface{}
+	t.stabbing(t.root, point, &ivs)
+	return vector.New(ivs...)
+}
+
+// Everything below here is private
+
+type node struct {
+	iv          Interval
+	left, right *node
+	size        int
+	maxHi       interface{} // the highest Hi here and below
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// Panic if the interval is not an Interval with Lo before Hi
+func (t *Tree) check(x interface{}) Interval {
+	iv, ok := x.(Interval)
+	if !ok {
+		panic(fmt.Sprintf("interval: %T item %v is not an Interval", x, x))
+	}
+	if t.cmp(iv.Lo, iv.Hi) >= 0 {
+		panic(fmt.Sprintf("interval: %v is empty", iv))
+	}
+	return iv
+}
+
+// Compare intervals by Lo and then by Hi
+func (t *Tree) order(a, b Interval) int {
+	if c := t.cmp(a.Lo, b.Lo); c != 0 {
+		return c
+	}
+	return t.cmp(a.Hi, b.Hi)
+}
+
+func (t *Tree) node(iv Interval, left, right *node) *node {
+	maxHi := iv.Hi
+	if left != nil && t.cmp(left.maxHi, maxHi) > 0 {
+		maxHi = left.maxHi
+	}
+	if right != nil && t.cmp(right.maxHi, maxHi) > 0 {
+		maxHi = right.maxHi
+	}
+	return &node{iv, left, right, 1 + size(left) + size(right), maxHi}
+}
+
+// Build a perfectly balanced tree of sorted intervals. O(n)
+func (t *Tree) build(ivs []Interval) *node {
+	if len(ivs) == 0 {
+		return nil
+	}
+	mid := len(ivs) / 2
+	return t.node(ivs[mid], t.build(ivs[:mid]), t.build(ivs[mid+1:]))
+}
+
+// The tree is weight-balanced, like ordered sets
+const (
+	delta = 3
+	gamma = 2
+)
+
+func weight(n *node) int { return size(n) + 1 }
+
+// Return a node of iv between left and right, which were balanced
+// before one of them gained or lost an interval. O(1)
+func (t *Tree) balance(iv Interval, left, right *node) *node {
+	switch {
+	case delta*weight(left) < weight(right):
+		r := right
+		if weight(r.left) < gamma*weight(r.right) {
+			return t.node(r.iv, t.node(iv, left, r.left), r.right)
+		}
+		rl := r.left
+		return t.node(rl.iv, t.node(iv, left, rl.left), t.node(r.iv, rl.right, r.right))
+	case delta*weight(right) < weight(left):
+		l := left
+		if weight(l.right) < gamma*weight(l.left) {
+			return t.node(l.iv, l.left, t.node(iv, l.right, right))
+		}
+		lr := l.right
+		return t.node(lr.iv, t.node(l.iv, l.left, lr.left), t.node(iv, lr.right, right))
+	}
+	return t.node(iv, left, right)
+}
+
+// Intervals with the same bounds go after the ones already there.
+// O(log n)
+func (t *Tree) insert(n *node, iv Interval) *node {
+	if n == nil {
+		return t.node(iv, nil, nil)
+	}
+	if t.order(iv, n.iv) < 0 {
+		return t.balance(n.iv, t.insert(n.left, iv), n.right)
+	}
+	return t.balance(n.iv, n.left, t.insert(n.right, iv))
+}
+
+// Return n without the first interval equal to iv, or n itself if there
+// is none. O(log n) plus the intervals with the same bounds
+func (t *Tree) remove(n *node, iv Interval) *node {
+	if n == nil {
+		return nil
+	}
+	c := t.order(iv, n.iv)
+	if c <= 0 {
+		if left := t.remove(n.left, iv); left != n.left {
+			return t.balance(n.iv, left, n.right)
+		}
+	}
+	if c == 0 && immut.Identical(iv.Value, n.iv.Value) {
+		return t.merge(n.left, n.right)
+	}
+	if c >= 0 {
+		if right := t.remove(n.right, iv); right != n.right {
+			return t.balance(n.iv, n.left, right)
+		}
+	}
+	return n
+}
+
+// Return a tree of left followed by right, which were balanced with
+// each other. O(log n)
+func (t *Tree) merge(left, right *node) *node {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	}
+	first := right
+	for first.left != nil {
+		first = first.left
+	}
+	return t.balance(first.iv, left, t.removeFirst(right))
+}
+
+func (t *Tree) removeFirst(n *node) *node {
+	if n.left == nil {
+		return n.right
+	}
+	return t.balance(n.iv, t.removeFirst(n.left), n.right)
+}
+
+// Whether the first interval equal to iv is there. O(log n) plus the
+// intervals with the same bounds
+func (t *Tree) find(n *node, iv Interval) bool {
+	if n == nil {
+		return false
+	}
+	c := t.order(iv, n.iv)
+	return c == 0 && immut.Identical(iv.Value, n.iv.Value) ||
+		c <= 0 && t.find(n.left, iv) ||
+		c >= 0 && t.find(n.right, iv)
+}
+
+func (t *Tree) overlapping(n *node, lo, hi interface{}, ivs *[]interface{}) {
+	if n == nil || t.cmp(n.maxHi, lo) <= 0 {
+		// nothing here ends after lo
+		return
+	}
+	t.overlapping(n.left, lo, hi, ivs)
+	if t.cmp(n.iv.Lo, hi) >= 0 {
+		// this and everything on the right starts at or after hi
+		return
+	}
+	if t.cmp(n.iv.Hi, lo) > 0 {
+		*ivs = append(*ivs, n.iv)
+	}
+	t.overlapping(n.right, lo, hi, ivs)
+}
+
+func (t *Tree) stabbing(n *node, point interface{}, ivs *[]interface{}) {
+	if n == nil || t.cmp(n.maxHi, point) <= 0 {
+		return
+	}
+	t.stabbing(n.left, point, ivs)
+	if t.cmp(n.iv.Lo, point) > 0 {
+		return
+	}
+	if t.cmp(n.iv.Hi, point) > 0 {
+		*ivs = append(*ivs, n.iv)
+	}
+	t.stabbing(n.right, point, ivs)
+}
+
+func (n *node) forall(f func(Interval) bool) bool {
+	return n == nil || n.left.forall(f) && f(n.iv) && n.right.forall(f)
+}
+
+// O(log n)
+func (t *Tree) add(iv Interval) *Tree {
+	t.check(iv)
+	return &Tree{t.cmp, t.insert(t.root, iv)}
+}
+
+// O(1)
+func (t *Tree) Len() int { return size(t.root) }
+
+// O(log n)
+func (t *Tree) Get(i int) (interface{}, bool) {
+	if i < 0 || i >= t.Len() {
+		return nil, false
+	}
+	n := t.root
+	for {
+		k := size(n.left)
+		switch {
+		case i < k:
+			n = n.left
+		case i == k:
+			return n.iv, true
+		default:
+			i -= k + 1
+			n = n.right
+		}
+	}
+}
+
+// O(log n) plus the intervals with the same bounds
+func (t *Tree) Contains(x interface{}) bool {
+	iv, ok := x.(Interval)
+	return ok && t.find(t.root, iv)
+}
+
+// O(log n)
+func (t *Tree) Front() interface{} {
+	if t.root == nil {
+		panic("getting Front of empty seq")
+	}
+	n := t.root
+	for n.left != nil {
+		n = n.left
+	}
+	return n.iv
+}
+
+// O(log n)
+func (t *Tree) Back() interface{} {
+	if t.root == nil {
+		panic("getting Back of empty seq")
+	}
+	n := t.root
+	for n.right != nil {
+		n = n.right
+	}
+	return n.iv
+}
+
+// O(log n)
+func (t *Tree) Rest() immut.Seq {
+	if t.root == nil {
+		panic("getting Rest of empty seq")
+	}
+	return &Tree{t.cmp, t.removeFirst(t.root)}
+}
+
+// O(1)
+func (t *Tree) IsEmpty() bool { return t.root == nil }
+
+// O(n)
+func (t *Tree) Do(f func(interface{})) {
+	t.root.forall(func(iv Interval) bool {
+		f(iv)
+		return true
+	})
+}
+
+// O(n)
+func (t *Tree) DoBackwards(f func(interface{})) {
+	items := t.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		f(items[i])
+	}
+}
+
+// O(n)
+func (t *Tree) Join(sep string, out io.Writer) {
+	s := ""
+	t.Do(func(x interface{}) {
+		fmt.Fprintf(out, "%s%v", s, x)
+		s = sep
+	})
+}
+
+// Cannot reverse a sorted set, so just return the set itself
+func (t *Tree) Reverse() immut.Seq { return t }
+
+// Kept in order of bounds, so an immut.Set
+func (t *Tree) IsSet() {}
+
+// O(log n)
+func (t *Tree) AddFront(x interface{}) immut.Seq { return t.add(t.check(x)) }
+
+// O(log n)
+func (t *Tree) AddBack(x interface{}) immut.Seq { return t.add(t.check(x)) }
+
+// O(m*log(n+m))
+func (t *Tree) AddAll(that immut.Seq) immut.Seq {
+	result := t
+	that.Do(func(x interface{}) { result = result.add(t.check(x)) })
+	return result
+}
+
+// O(n), visiting intervals in order
+func (t *Tree) Forall(f func(interface{}) bool) bool {
+	return t.root.forall(func(iv Interval) bool { return f(iv) })
+}
+
+// O(n*log(n)), panicking if f returns something that is not an Interval
+func (t *Tree) Map(f func(interface{}) interface{}) immut.Seq {
+	ivs := make([]Interval, 0, t.Len())
+	t.Do(func(x interface{}) { ivs = append(ivs, t.check(f(x))) })
+	return New(t.cmp, ivs...)
+}
+
+// O(n)
+func (t *Tree) Filter(f func(interface{}) bool) immut.Seq {
+	ivs := make([]Interval, 0, t.Len())
+	t.root.forall(func(iv Interval) bool {
+		if f(iv) {
+			ivs = append(ivs, iv)
+		}
+		return true
+	})
+	if len(ivs) == t.Len() {
+		return t
+	}
+	return &Tree{t.cmp, t.build(ivs)}
+}
+
+// Remove returns the tree without the first interval equal to x, which
+// has the same bounds and an identical Value. O(log n) plus the
+// intervals with the same bounds
+func (t *Tree) Remove(x interface{}) immut.Seq {
+	iv, ok := x.(Interval)
+	if !ok {
+		return t
+	}
+	root := t.remove(t.root, iv)
+	if root == t.root {
+		return t
+	}
+	return &Tree{t.cmp, root}
+}
+
+// O(n)
+func (t *Tree) Items() []interface{} {
+	ys := make([]interface{}, 0, t.Len())
+	t.Do(func(x interface{}) { ys = append(ys, x) })
+	return ys
+}
+
+func (t *Tree) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	t.Join(",", &buf)
+	buf.WriteString("}")
+	return buf.String()
+}
@@ -0,0 +1,55 @@
+package intmap_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/intmap"
+)
+
+func Example() {
+	names := intmap.New(3, "ship", 1, "rock", -2, "sun")
+	fmt.Println(names)
+	fmt.Println(names.Get(3))
+	fmt.Println(names.Dissoc(1).Keys())
+	fmt.Println(names.(*intmap.Map).Values())
+	fmt.Println(names.(*intmap.Map).Range(0, 10))
+	// Output:
+	// {-2:sun,1:rock,3:ship}
+	// ship true
+	// [-2,3]
+	// [sun,rock,ship]
+	// {1:rock,3:ship}
+}
+
+type vec struct{ x, y int }
+
+func ExampleMap_IntersectionWith() {
+	// components of the entities in a game, keyed by entity ID
+	position := intmap.New(1, vec{0, 0}, 2, vec{5, 5}, 3, vec{9, 9}).(*intmap.Map)
+	velocity := intmap.New(2, vec{1, 0}, 3, vec{0, -1}, 4, vec{2, 2}).(*intmap.Map)
+
+	// move the entities that have both
+	moved := position.IntersectionWith(velocity, func(p, v interface{}) interface{} {
+		return vec{p.(vec).x + v.(vec).x, p.(vec).y + v.(vec).y}
+	})
+	position = position.UnionWith(moved, func(_, p interface{}) interface{} { return p })
+	fmt.Println(position)
+
+	// the positions of the entities that can move
+	fmt.Println(position.IntersectionWith(velocity, nil))
+	// Output:
+	// {1:{0 0},2:{6 5},3:{9 8}}
+	// {2:{6 5},3:{9 8}}
+}
+
+func ExampleMap_UnionWith() {
+	a := intmap.New(1, 10, 2, 20).(*intmap.Map)
+	b := intmap.New(2, 2, 3, 3).(*intmap.Map)
+	sum := func(x, y interface{}) interface{} { return x.(int) + y.(int) }
+	fmt.Println(a.UnionWith(b, sum))
+	fmt.Println(a.UnionWith(b, nil))
+	fmt.Println(a.UnionWith(a, sum))
+	// Output:
+	// {1:10,2:22,3:3}
+	// {1:10,2:20,3:3}
+	// {1:20,2:40}
+}
@@ -0,0 +1,34 @@
+package intmap
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "github.com/eobrain/immut/internal/gobitems"
+
+func init() {
+	gobitems.Register("intmap.Map", &Map{})
+}
+
+// GobEncode writes the keys and values of the map, alternating.
+func (m *Map) GobEncode() ([]byte, error) { return gobitems.EncodeMap(m) }
+
+// GobDecode rebuilds the map from its keys and values.
+func (m *Map) GobDecode(data []byte) error {
+	items, err := gobitems.Decode(data)
+	if err != nil {
+		return err
+	}
+	*m = *New(items...).(*Map)
+	return nil
+}
@@ -0,0 +1,472 @@
+// The intmap package provides persistent maps keyed by ints, as
+// big-endian Patricia tries (Okasaki and Gill 1998).  Lookups and
+// updates take time proportional to the number of bits in a key at
+// most, and two maps can be merged sharing every subtree whose keys
+// only one of them has.
+package intmap
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/vector"
+	"math/bits"
+)
+
+// Create a new map from alternating keys and values, where the keys
+// must be integers that fit in an int. O(n*min(n,64))
+func New(keyValues ...interface{}) immut.Map {
+	if len(keyValues)%2 != 0 {
+		panic("odd number of arguments to New")
+	}
+	m := &Map{}
+	for i := 0; i < len(keyValues); i += 2 {
+		m = m.assoc(key(keyValues[i]), keyValues[i+1])
+	}
+	return m
+}
+
+// A Map from ints implemented as a Patricia trie, which may be empty
+type Map struct {
+	root node // nil if empty
+}
+
+// UnionWith returns the entries of both maps, calling f with the value
+// from m and the value from other for the keys in both.  If f is nil
+// the value from m is kept.  Subtrees of keys in only one map are
+// shared with it. O(n+m), less where the key ranges do not overlap
+func (m *Map) UnionWith(other *Map, f func(a, b interface{}) interface{}) *Map {
+	if f == nil {
+		f = func(a, _ interface{}) interface{} { return a }
+	}
+	root := union(m.root, other.root, f)
+	switch root {
+	case m.root:
+		return m
+	case other.root:
+		return other
+	}
+	return &Map{root}
+}
+
+// IntersectionWith returns the keys in both maps, with their values
+// from calling f with the value from m and the value from other.  If f
+// is nil the value from m is kept. O(n+m), less where the key ranges do
+// not overlap
+func (m *Map) IntersectionWith(other *Map, f func(a, b interface{}) interface{}) *Map {
+	if f == nil {
+		f = func(a, _ interface{}) interface{} { return a }
+	}
+	return &Map{intersection(m.root, other.root, f)}
+}
+
+// Range returns the entries with keys from lo up to but not including
+// hi, sharing the subtrees that are entirely within the range.
+// O(log n) plus the number of branches on the boundaries
+func (m *Map) Range(lo, hi int) *Map {
+	if lo >= hi {
+		return &Map{}
+	}
+	root := between(m.root, encode(lo), encode(hi-1))
+	if root == m.root {
+		return m
+	}
+	return &Map{root}
+}
+
+// Values returns the values in order of their keys, as a vector. O(n)
+func (m *Map) Values() immut.Seq {
+	values := make([]interface{}, 0, m.Len())
+	m.Do(func(_, v interface{}) { values = append(values, v) })
+	return vector.New(values...)
+}
+
+// Everything below here is private
+
+// Keys are stored with the sign bit flipped, so that the unsigned order
+// of the trie is the signed order of the keys
+func encode(k int) uint64 { return uint64(k) ^ 1<<63 }
+func decode(u uint64) int { return int(u ^ 1<<63) }
+func key(x interface{}) uint64 {
+	u, ok := toKey(x)
+	if !ok {
+		panic(fmt.Sprintf("intmap: %T key %v is not an int", x, x))
+	}
+	return u
+}
+
+func toKey(x interface{}) (uint64, bool) {
+	switch x := x.(type) {
+	case int:
+		return encode(x), true
+	case int64:
+		return encode(int(x)), int64(int(x)) == x
+	case int32:
+		return encode(int(x)), true
+	case int16:
+		return encode(int(x)), true
+	case int8:
+		return encode(int(x)), true
+	case uint64:
+		return encode(int(x)), int(x) >= 0
+	case uint32:
+		return encode(int(x)), int(x) >= 0
+	case uint:
+		return encode(int(x)), int(x) >= 0
+	case uint16:
+		return encode(int(x)), true
+	case uint8:
+		return encode(int(x)), true
+	}
+	return 0, false
+}
+
+// A node is a *leaf or a *branch
+type node interface {
+	size() int
+	forall(f func(k uint64, v interface{}) bool) bool
+}
+
+type leaf struct {
+	key   uint64
+	value interface{}
+}
+
+// All the keys below a branch share the prefix above the branching bit,
+// and have the bit clear on the left and set on the right
+type branch struct {
+	prefix      uint64 // the shared high bits, with the rest clear
+	bit         uint64 // the highest bit where the keys differ
+	left, right node
+	n           int
+}
+
+func (*leaf) size() int     { return 1 }
+func (b *branch) size() int { return b.n }
+
+func (l *leaf) forall(f func(k uint64, v interface{}) bool) bool { return f(l.key, l.value) }
+func (b *branch) forall(f func(k uint64, v interface{}) bool) bool {
+	return b.left.forall(f) && b.right.forall(f)
+}
+
+// The bits of k above bit
+func mask(k, bit uint64) uint64 { return k &^ (bit | (bit - 1)) }
+
+// Whether k has the prefix of the branch
+func (b *branch) matches(k uint64) bool { return mask(k, b.bit) == b.prefix }
+
+// The largest key that could be below the branch
+func (b *branch) last() uint64 { return b.prefix | b.bit | (b.bit - 1) }
+
+func newBranch(prefix, bit uint64, left, right node) *branch {
+	return &branch{prefix, bit, left, right, left.size() + right.size()}
+}
+
+// Return a branch, or just one side if the other is nil
+func makeBranch(prefix, bit uint64, left, right node) node {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	}
+	return newBranch(prefix, bit, left, right)
+}
+
+// Combine two nodes whose keys have the different prefixes p0 and p1
+func join(p0 uint64, t0 node, p1 uint64, t1 node) node {
+	bit := uint64(1) << (63 - bits.LeadingZeros64(p0^p1))
+	if p0&bit == 0 {
+		return newBranch(mask(p0, bit), bit, t0, t1)
+	}
+	return newBranch(mask(p0, bit), bit, t1, t0)
+}
+
+// O(min(n,64))
+func lookup(t node, k uint64) (*leaf, bool) {
+	for {
+		switch tt := t.(type) {
+		case *leaf:
+			return tt, tt.key == k
+		case *branch:
+			if !tt.matches(k) {
+				return nil, false
+			}
+			if k&tt.bit == 0 {
+				t = tt.left
+			} else {
+				t = tt.right
+			}
+		default:
+			return nil, false
+		}
+	}
+}
+
+// Return t with k associated with the result of calling f with v and
+// any value k already had, or t itself if that does not change it.
+// O(min(n,64))
+func insert(t node, k uint64, v interface{}, f func(a, b interface{}) interface{}) node {
+	switch tt := t.(type) {
+	case nil:
+		return &leaf{k, v}
+	case *leaf:
+		if tt.key != k {
+			return join(k, &leaf{k, v}, tt.key, tt)
+		}
+		v = f(v, tt.value)
+		if immut.Identical(v, tt.value) {
+			return tt
+		}
+		return &leaf{k, v}
+	}
+	b := t.(*branch)
+	if !b.matches(k) {
+		return join(k, &leaf{k, v}, b.prefix, b)
+	}
+	if k&b.bit == 0 {
+		left := insert(b.left, k, v, f)
+		if left == b.left {
+			return b
+		}
+		return newBranch(b.prefix, b.bit, left, b.right)
+	}
+	right := insert(b.right, k, v, f)
+	if right == b.right {
+		return b
+	}
+	return newBranch(b.prefix, b.bit, b.left, right)
+}
+
+// Return t without k, t itself if k is not there, or nil if nothing is
+// left. O(min(n,64))
+func remove(t node, k uint64) node {
+	switch tt := t.(type) {
+	case *leaf:
+		if tt.key == k {
+			return nil
+		}
+		return tt
+	case *branch:
+		if !tt.matches(k) {
+			return tt
+		}
+		if k&tt.bit == 0 {
+			left := remove(tt.left, k)
+			if left == tt.left {
+				return tt
+			}
+			return makeBranch(tt.prefix, tt.bit, left, tt.right)
+		}
+		right := remove(tt.right, k)
+		if right == tt.right {
+			return tt
+		}
+		return makeBranch(tt.prefix, tt.bit, tt.left, right)
+	}
+	return t
+}
+
+func flip(f func(a, b interface{}) interface{}) func(a, b interface{}) interface{} {
+	return func(a, b interface{}) interface{} { return f(b, a) }
+}
+
+// Merge two tries, calling f with the value from s and the value from
+// t for keys in both
+func union(s, t node, f func(a, b interface{}) interface{}) node {
+	switch {
+	case s == nil:
+		return t
+	case t == nil:
+		return s
+	}
+	if l, ok := s.(*leaf); ok {
+		return insert(t, l.key, l.value, f)
+	}
+	if l, ok := t.(*leaf); ok {
+		return insert(s, l.key, l.value, flip(f))
+	}
+	sb, tb := s.(*branch), t.(*branch)
+	var left, right node
+	switch {
+	case sb.bit == tb.bit && sb.prefix == tb.prefix:
+		left, right = union(sb.left, tb.left, f), union(sb.right, tb.right, f)
+	case sb.bit > tb.bit && sb.matches(tb.prefix):
+		// t goes inside s
+		left, right = sb.left, sb.right
+		if tb.prefix&sb.bit == 0 {
+			left = union(sb.left, t, f)
+		} else {
+			right = union(sb.right, t, f)
+		}
+	case tb.bit > sb.bit && tb.matches(sb.prefix):
+		// s goes inside t
+		left, right = tb.left, tb.right
+		if sb.prefix&tb.bit == 0 {
+			left = union(s, tb.left, f)
+		} else {
+			right = union(s, tb.right, f)
+		}
+		if left == tb.left && right == tb.right {
+			return t
+		}
+		return newBranch(tb.prefix, tb.bit, left, right)
+	default:
+		return join(sb.prefix, s, tb.prefix, t)
+	}
+	if left == sb.left && right == sb.right {
+		return s
+	}
+	return newBranch(sb.prefix, sb.bit, left, right)
+}
+
+// Return the keys in both tries, with values from calling f with the
+// value from s and the value from t, or nil if there are none
+func intersection(s, t node, f func(a, b interface{}) interface{}) node {
+	if s == nil || t == nil {
+		return nil
+	}
+	if l, ok := s.(*leaf); ok {
+		if lt, ok := lookup(t, l.key); ok {
+			return &leaf{l.key, f(l.value, lt.value)}
+		}
+		return nil
+	}
+	if l, ok := t.(*leaf); ok {
+		if ls, ok := lookup(s, l.key); ok {
+			return &leaf{l.key, f(ls.value, l.value)}
+		}
+		return nil
+	}
+	sb, tb := s.(*branch), t.(*branch)
+	switch {
+	case sb.bit == tb.bit && sb.prefix == tb.prefix:
+		return makeBranch(sb.prefix, sb.bit,
+			intersection(sb.left, tb.left, f), intersection(sb.right, tb.right, f))
+	case sb.bit > tb.bit && sb.matches(tb.prefix):
+		if tb.prefix&sb.bit == 0 {
+			return intersection(sb.left, t, f)
+		}
+		return intersection(sb.right, t, f)
+	case tb.bit > sb.bit && tb.matches(sb.prefix):
+		if sb.prefix&tb.bit == 0 {
+			return intersection(s, tb.left, f)
+		}
+		return intersection(s, tb.right, f)
+	}
+	return nil
+}
+
+// Return the part of t with keys from lo to hi inclusive
+func between(t node, lo, hi uint64) node {
+	switch tt := t.(type) {
+	case *leaf:
+		if lo <= tt.key && tt.key <= hi {
+			return tt
+		}
+		return nil
+	case *branch:
+		first, last := tt.prefix, tt.last()
+		switch {
+		case hi < first || last < lo:
+			return nil
+		case lo <= first && last <= hi:
+			return tt
+		}
+		left, right := between(tt.left, lo, hi), between(tt.right, lo, hi)
+		if left == tt.left && right == tt.right {
+			return tt
+		}
+		return makeBranch(tt.prefix, tt.bit, left, right)
+	}
+	return nil
+}
+
+// O(min(n,64))
+func (m *Map) assoc(k uint64, v interface{}) *Map {
+	root := insert(m.root, k, v, func(a, _ interface{}) interface{} { return a })
+	if root == m.root {
+		return m
+	}
+	return &Map{root}
+}
+
+// O(1)
+func (m *Map) Len() int {
+	if m.root == nil {
+		return 0
+	}
+	return m.root.size()
+}
+
+// O(min(n,64))
+func (m *Map) Get(k interface{}) (interface{}, bool) {
+	u, ok := toKey(k)
+	if !ok {
+		return nil, false
+	}
+	l, ok := lookup(m.root, u)
+	if !ok {
+		return nil, false
+	}
+	return l.value, true
+}
+
+// O(min(n,64))
+func (m *Map) Assoc(k, v interface{}) immut.Map { return m.assoc(key(k), v) }
+
+// O(min(n,64))
+func (m *Map) Dissoc(k interface{}) immut.Map {
+	u, ok := toKey(k)
+	if !ok {
+		return m
+	}
+	root := remove(m.root, u)
+	if root == m.root {
+		return m
+	}
+	return &Map{root}
+}
+
+// The keys in order, as a vector of ints. O(n)
+func (m *Map) Keys() immut.Seq {
+	keys := make([]interface{}, 0, m.Len())
+	m.Do(func(k, _ interface{}) { keys = append(keys, k) })
+	return vector.New(keys...)
+}
+
+// O(n), visiting keys in order
+func (m *Map) Do(f func(key, value interface{})) {
+	if m.root != nil {
+		m.root.forall(func(k uint64, v interface{}) bool {
+			f(decode(k), v)
+			return true
+		})
+	}
+}
+
+func (m *Map) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	sep := ""
+	m.Do(func(k, v interface{}) {
+		fmt.Fprintf(&buf, "%s%v:%v", sep, k, v)
+		sep = ","
+	})
+	buf.WriteString("}")
+	return buf.String()
+}
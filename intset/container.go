@@ -0,0 +1,473 @@
+package intset
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Everything below here is private
+
+// Each chunk of the set holds the low 16 bits of the values that share
+// their high bits, in whichever of three kinds of container is smallest.
+// Containers are never modified once made, so they can be shared
+// between versions of a set, and changing one copies only it.
+type container interface {
+	card() int
+	contains(x uint16) bool
+
+	// Return the container with x added, or itself if x is already there
+	add(x uint16) container
+
+	// Return the container without x, itself if x is not there, or nil
+	// if nothing is left
+	remove(x uint16) container
+
+	// Call f on each value in ascending order, stopping if it returns
+	// false
+	forall(f func(uint16) bool) bool
+
+	nth(i int) uint16
+	bytes() int
+}
+
+const (
+	arrayMax    = 4096 // an array container bigger than this would be bigger than a bitmap
+	bitmapBytes = 1 << 16 / 8
+	runMax      = bitmapBytes / 4 // a run container bigger than this would be bigger than a bitmap
+)
+
+// Sorted values, for sparse chunks
+type arrayContainer struct{ xs []uint16 }
+
+// One bit for each possible value, for dense chunks
+type bitmapContainer struct {
+	words *[1 << 16 / 64]uint64
+	n     int
+}
+
+// Sorted disjoint runs of consecutive values, for chunks of ranges
+type runContainer struct {
+	runs []run
+	n    int
+}
+
+type run struct{ start, last uint16 }
+
+func (c *arrayContainer) card() int  { return len(c.xs) }
+func (c *bitmapContainer) card() int { return c.n }
+func (c *runContainer) card() int    { return c.n }
+
+func (c *arrayContainer) bytes() int  { return 2 * len(c.xs) }
+func (c *bitmapContainer) bytes() int { return bitmapBytes }
+func (c *runContainer) bytes() int    { return 4 * len(c.runs) }
+
+// Index of x in the array, or where it would go
+func (c *arrayContainer) search(x uint16) (int, bool) {
+	i := sort.Search(len(c.xs), func(i int) bool { return c.xs[i] >= x })
+	return i, i < len(c.xs) && c.xs[i] == x
+}
+
+// Index of the run containing x, or of the first run after x
+func (c *runContainer) search(x uint16) (int, bool) {
+	i := sort.Search(len(c.runs), func(i int) bool { return c.runs[i].last >= x })
+	return i, i < len(c.runs) && c.runs[i].start <= x
+}
+
+func (c *arrayContainer) contains(x uint16) bool {
+	_, ok := c.search(x)
+	return ok
+}
+func (c *bitmapContainer) contains(x uint16) bool { return c.words[x/64]&(1<<(x%64)) != 0 }
+func (c *runContainer) contains(x uint16) bool {
+	_, ok := c.search(x)
+	return ok
+}
+
+// O(len)
+func (c *arrayContainer) add(x uint16) container {
+	i, ok := c.search(x)
+	if ok {
+		return c
+	}
+	if len(c.xs) == arrayMax {
+		return toBitmap(c).add(x)
+	}
+	xs := make([]uint16, len(c.xs)+1)
+	copy(xs, c.xs[:i])
+	xs[i] = x
+	copy(xs[i+1:], c.xs[i:])
+	return &arrayContainer{xs}
+}
+
+// O(1) words, but copies the bitmap
+func (c *bitmapContainer) add(x uint16) container {
+	if c.contains(x) {
+		return c
+	}
+	words := *c.words
+	words[x/64] |= 1 << (x % 64)
+	return &bitmapContainer{&words, c.n + 1}
+}
+
+// O(runs)
+func (c *runContainer) add(x uint16) container {
+	i, ok := c.search(x)
+	if ok {
+		return c
+	}
+	joinPrev := i > 0 && c.runs[i-1].last == x-1
+	joinNext := i < len(c.runs) && x < 1<<16-1 && c.runs[i].start == x+1
+	var runs []run
+	switch {
+	case joinPrev && joinNext:
+		runs = append(append(runs, c.runs[:i-1]...), run{c.runs[i-1].start, c.runs[i].last})
+		runs = append(runs, c.runs[i+1:]...)
+	case joinPrev:
+		runs = append(runs, c.runs...)
+		runs[i-1].last = x
+	case joinNext:
+		runs = append(runs, c.runs...)
+		runs[i].start = x
+	default:
+		if len(c.runs) == runMax {
+			return toBitmap(c).add(x)
+		}
+		runs = append(append(runs, c.runs[:i]...), run{x, x})
+		runs = append(runs, c.runs[i:]...)
+	}
+	return &runContainer{runs, c.n + 1}
+}
+
+// O(len)
+func (c *arrayContainer) remove(x uint16) container {
+	i, ok := c.search(x)
+	switch {
+	case !ok:
+		return c
+	case len(c.xs) == 1:
+		return nil
+	}
+	xs := make([]uint16, 0, len(c.xs)-1)
+	return &arrayContainer{append(append(xs, c.xs[:i]...), c.xs[i+1:]...)}
+}
+
+// O(1) words, but copies the bitmap
+func (c *bitmapContainer) remove(x uint16) container {
+	if !c.contains(x) {
+		return c
+	}
+	words := *c.words
+	words[x/64] &^= 1 << (x % 64)
+	result := &bitmapContainer{&words, c.n - 1}
+	if result.n <= arrayMax {
+		return toArray(result)
+	}
+	return result
+}
+
+// O(runs)
+func (c *runContainer) remove(x uint16) container {
+	i, ok := c.search(x)
+	switch {
+	case !ok:
+		return c
+	case c.n == 1:
+		return nil
+	}
+	r := c.runs[i]
+	runs := append([]run(nil), c.runs[:i]...)
+	if r.start < x {
+		runs = append(runs, run{r.start, x - 1})
+	}
+	if x < r.last {
+		runs = append(runs, run{x + 1, r.last})
+	}
+	return &runContainer{append(runs, c.runs[i+1:]...), c.n - 1}
+}
+
+func (c *arrayContainer) forall(f func(uint16) bool) bool {
+	for _, x := range c.xs {
+		if !f(x) {
+			return false
+		}
+	}
+	return true
+}
+func (c *bitmapContainer) forall(f func(uint16) bool) bool {
+	for i, w := range c.words {
+		for ; w != 0; w &= w - 1 {
+			if !f(uint16(64*i + bits.TrailingZeros64(w))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+func (c *runContainer) forall(f func(uint16) bool) bool {
+	for _, r := range c.runs {
+		for x := int(r.start); x <= int(r.last); x++ {
+			if !f(uint16(x)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// O(1)
+func (c *arrayContainer) nth(i int) uint16 { return c.xs[i] }
+
+// O(words)
+func (c *bitmapContainer) nth(i int) uint16 {
+	for j, w := range c.words {
+		n := bits.OnesCount64(w)
+		if i < n {
+			for ; i > 0; i-- {
+				w &= w - 1
+			}
+			return uint16(64*j + bits.TrailingZeros64(w))
+		}
+		i -= n
+	}
+	panic("cannot happen")
+}
+
+// O(runs)
+func (c *runContainer) nth(i int) uint16 {
+	for _, r := range c.runs {
+		n := int(r.last) - int(r.start) + 1
+		if i < n {
+			return r.start + uint16(i)
+		}
+		i -= n
+	}
+	panic("cannot happen")
+}
+
+func toArray(c container) *arrayContainer {
+	if a, ok := c.(*arrayContainer); ok {
+		return a
+	}
+	xs := make([]uint16, 0, c.card())
+	c.forall(func(x uint16) bool {
+		xs = append(xs, x)
+		return true
+	})
+	return &arrayContainer{xs}
+}
+
+func toBitmap(c container) *bitmapContainer {
+	switch c := c.(type) {
+	case *bitmapContainer:
+		return c
+	case *runContainer:
+		var words [1 << 16 / 64]uint64
+		for _, r := range c.runs {
+			setRange(&words, int(r.start), int(r.last)+1)
+		}
+		return &bitmapContainer{&words, c.n}
+	}
+	var words [1 << 16 / 64]uint64
+	c.forall(func(x uint16) bool {
+		words[x/64] |= 1 << (x % 64)
+		return true
+	})
+	return &bitmapContainer{&words, c.card()}
+}
+
+func toRuns(c container) *runContainer {
+	if r, ok := c.(*runContainer); ok {
+		return r
+	}
+	var runs []run
+	c.forall(func(x uint16) bool {
+		if n := len(runs); n > 0 && runs[n-1].last == x-1 {
+			runs[n-1].last = x
+		} else {
+			runs = append(runs, run{x, x})
+		}
+		return true
+	})
+	return &runContainer{runs, c.card()}
+}
+
+// Set the bits from lo up to but not including hi
+func setRange(words *[1 << 16 / 64]uint64, lo, hi int) {
+	for lo < hi {
+		w, b := lo/64, lo%64
+		n := min(64-b, hi-lo)
+		words[w] |= (^uint64(0) >> (64 - n)) << b
+		lo += n
+	}
+}
+
+// Number of runs of consecutive values. O(card) or O(words)
+func runCount(c container) int {
+	switch c := c.(type) {
+	case *runContainer:
+		return len(c.runs)
+	case *bitmapContainer:
+		n := 0
+		carry := uint64(0)
+		for _, w := range c.words {
+			// count the set bits whose lower neighbour is clear
+			n += bits.OnesCount64(w &^ (w<<1 | carry))
+			carry = w >> 63
+		}
+		return n
+	}
+	n, prev := 0, -2
+	c.forall(func(x uint16) bool {
+		if int(x) != prev+1 {
+			n++
+		}
+		prev = int(x)
+		return true
+	})
+	return n
+}
+
+// Return the smallest kind of container holding the same values, or
+// nil if it is empty
+func optimize(c container) container {
+	n := c.card()
+	if n == 0 {
+		return nil
+	}
+	runBytes := 4 * runCount(c)
+	switch {
+	case runBytes < 2*n && runBytes < bitmapBytes:
+		return toRuns(c)
+	case n <= arrayMax:
+		return toArray(c)
+	}
+	return toBitmap(c)
+}
+
+// Return the union of two containers, sharing one if the other adds
+// nothing to it
+func union(a, b container) container {
+	if a == b {
+		return a
+	}
+	ra, ok1 := a.(*runContainer)
+	rb, ok2 := b.(*runContainer)
+	if ok1 && ok2 {
+		return optimize(unionRuns(ra.runs, rb.runs))
+	}
+	aa, ok1 := a.(*arrayContainer)
+	ab, ok2 := b.(*arrayContainer)
+	if ok1 && ok2 && len(aa.xs)+len(ab.xs) <= arrayMax {
+		return optimize(unionArrays(aa.xs, ab.xs))
+	}
+	ba, bb := toBitmap(a), toBitmap(b)
+	var words [1 << 16 / 64]uint64
+	n := 0
+	for i := range words {
+		words[i] = ba.words[i] | bb.words[i]
+		n += bits.OnesCount64(words[i])
+	}
+	return optimize(&bitmapContainer{&words, n})
+}
+
+// Return the intersection of two containers, or nil if it is empty
+func intersect(a, b container) container {
+	if a == b {
+		return a
+	}
+	if _, ok := b.(*arrayContainer); ok {
+		a, b = b, a
+	}
+	if aa, ok := a.(*arrayContainer); ok {
+		xs := make([]uint16, 0, len(aa.xs))
+		for _, x := range aa.xs {
+			if b.contains(x) {
+				xs = append(xs, x)
+			}
+		}
+		return optimize(&arrayContainer{xs})
+	}
+	ra, ok1 := a.(*runContainer)
+	rb, ok2 := b.(*runContainer)
+	if ok1 && ok2 {
+		return optimize(intersectRuns(ra.runs, rb.runs))
+	}
+	ba, bb := toBitmap(a), toBitmap(b)
+	var words [1 << 16 / 64]uint64
+	n := 0
+	for i := range words {
+		words[i] = ba.words[i] & bb.words[i]
+		n += bits.OnesCount64(words[i])
+	}
+	return optimize(&bitmapContainer{&words, n})
+}
+
+func unionArrays(a, b []uint16) *arrayContainer {
+	xs := make([]uint16, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			xs, a = append(xs, a[0]), a[1:]
+		case b[0] < a[0]:
+			xs, b = append(xs, b[0]), b[1:]
+		default:
+			xs, a, b = append(xs, a[0]), a[1:], b[1:]
+		}
+	}
+	return &arrayContainer{append(append(xs, a...), b...)}
+}
+
+func unionRuns(a, b []run) *runContainer {
+	var runs []run
+	n := 0
+	for len(a) > 0 || len(b) > 0 {
+		var r run
+		if len(b) == 0 || len(a) > 0 && a[0].start <= b[0].start {
+			r, a = a[0], a[1:]
+		} else {
+			r, b = b[0], b[1:]
+		}
+		if k := len(runs); k > 0 && int(r.start) <= int(runs[k-1].last)+1 {
+			if r.last > runs[k-1].last {
+				n += int(r.last) - int(runs[k-1].last)
+				runs[k-1].last = r.last
+			}
+			continue
+		}
+		runs = append(runs, r)
+		n += int(r.last) - int(r.start) + 1
+	}
+	return &runContainer{runs, n}
+}
+
+func intersectRuns(a, b []run) *runContainer {
+	var runs []run
+	n := 0
+	for len(a) > 0 && len(b) > 0 {
+		start, last := max(a[0].start, b[0].start), min(a[0].last, b[0].last)
+		if start <= last {
+			runs = append(runs, run{start, last})
+			n += int(last) - int(start) + 1
+		}
+		if a[0].last < b[0].last {
+			a = a[1:]
+		} else {
+			b = b[1:]
+		}
+	}
+	return &runContainer{runs, n}
+}
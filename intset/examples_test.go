@@ -0,0 +1,43 @@
+package intset_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/intset"
+)
+
+func Example() {
+	admins := intset.Of(3, 7, 1000000, 1<<40)
+	active := intset.Range(0, 2000000).Remove(7).(*intset.Set)
+
+	both := admins.Intersect(active)
+	fmt.Println(both, both.Cardinality())
+	fmt.Println(admins.Union(active).Cardinality())
+	fmt.Println(active.Contains(uint32(7)), active.Contains(8))
+	// Output:
+	// {3,1000000} 2
+	// 2000001
+	// false true
+}
+
+func ExampleSet_SizeInBytes() {
+	// a million consecutive IDs, then every other one, then a few
+	dense := intset.Range(0, 1000000)
+	evens := dense.Filter(func(x interface{}) bool { return x.(uint64)%2 == 0 })
+	sparse := intset.New(10, 200000, 4000000)
+
+	fmt.Println(dense.Cardinality(), dense.SizeInBytes())
+	fmt.Println(evens.Len(), evens.(*intset.Set).SizeInBytes())
+	fmt.Println(sparse.Len(), sparse.(*intset.Set).SizeInBytes())
+	// Output:
+	// 1000000 576
+	// 500000 131584
+	// 3 102
+}
+
+func ExampleSet_Diff() {
+	v1 := intset.Range(0, 1000000)
+	v2 := v1.Remove(12345).AddFront(5000000)
+	fmt.Printf("%+v\n", v1.Diff(v2))
+	// Output:
+	// {Removed:[12345] Added:[5000000] Edits:[]}
+}
@@ -0,0 +1,123 @@
+package intset
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/eobrain/immut/internal/gobitems"
+	"math/bits"
+)
+
+func init() {
+	gobitems.Register("intset.Set", &Set{})
+}
+
+// GobEncode writes each chunk in the form it is kept in, so that a set
+// of ranges or a dense set stays small.
+func (xs *Set) GobEncode() ([]byte, error) {
+	chunks := make([]gobChunk, len(xs.chunks))
+	for i, ch := range xs.chunks {
+		chunks[i].Key = ch.key
+		switch c := ch.c.(type) {
+		case *arrayContainer:
+			chunks[i].Array = c.xs
+		case *bitmapContainer:
+			chunks[i].Bitmap = c.words[:]
+		case *runContainer:
+			for _, r := range c.runs {
+				chunks[i].Runs = append(chunks[i].Runs, r.start, r.last)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(chunks)
+	return buf.Bytes(), err
+}
+
+// GobDecode rebuilds the set from its chunks.
+func (xs *Set) GobDecode(data []byte) error {
+	var chunks []gobChunk
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&chunks); err != nil {
+		return err
+	}
+	result := Set{}
+	for _, ch := range chunks {
+		c, err := ch.container()
+		if err != nil {
+			return err
+		}
+		if len(result.chunks) > 0 && result.chunks[len(result.chunks)-1].key >= ch.Key {
+			return errors.New("intset: chunks out of order")
+		}
+		result.chunks = append(result.chunks, chunk{ch.Key, c})
+		result.n += c.card()
+	}
+	*xs = result
+	return nil
+}
+
+// Everything below here is private
+
+// One chunk of a set as written by gob, with one of the fields set
+type gobChunk struct {
+	Key    uint64
+	Array  []uint16
+	Bitmap []uint64
+	Runs   []uint16 // starts and lasts, alternating
+}
+
+func (ch gobChunk) container() (container, error) {
+	var c container
+	switch {
+	case ch.Bitmap != nil:
+		words := new([1 << 16 / 64]uint64)
+		if len(ch.Bitmap) != len(words) {
+			return nil, errors.New("intset: bitmap of wrong size")
+		}
+		n := 0
+		for i, w := range ch.Bitmap {
+			words[i] = w
+			n += bits.OnesCount64(w)
+		}
+		c = &bitmapContainer{words, n}
+	case ch.Runs != nil:
+		if len(ch.Runs)%2 != 0 {
+			return nil, errors.New("intset: odd number of run bounds")
+		}
+		r := &runContainer{make([]run, len(ch.Runs)/2), 0}
+		for i := range r.runs {
+			start, last := ch.Runs[2*i], ch.Runs[2*i+1]
+			if last < start || i > 0 && int(start) <= int(r.runs[i-1].last)+1 {
+				return nil, errors.New("intset: runs out of order")
+			}
+			r.runs[i] = run{start, last}
+			r.n += int(last) - int(start) + 1
+		}
+		c = r
+	default:
+		for i := 1; i < len(ch.Array); i++ {
+			if ch.Array[i] <= ch.Array[i-1] {
+				return nil, errors.New("intset: values out of order")
+			}
+		}
+		c = &arrayContainer{ch.Array}
+	}
+	if c.card() == 0 {
+		return nil, errors.New("intset: empty chunk")
+	}
+	return optimize(c), nil
+}
@@ -0,0 +1,489 @@
+// The intset package provides persistent sets of unsigned integers as
+// compressed bitmaps in the style of Roaring (Chambi, Lemire, Kaser and
+// Godin 2016).  The values are split into chunks of 65536 by their high
+// bits, and each chunk is kept as a sorted array, a bitmap or a list of
+// runs, whichever is smallest.  Dense ranges of IDs take around one bit
+// each, or a few bytes per range.
+package intset
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"io"
+	"sort"
+)
+
+// Create a new set containing the arguments, which must be non-negative
+// integers of any integer type.  The items of the set are uint64s.
+// O(n*log(n))
+func New(item ...interface{}) immut.Seq {
+	xs := make([]uint64, len(item))
+	for i, x := range item {
+		xs[i] = value(x)
+	}
+	return build(xs)
+}
+
+// Create a new set containing the arguments. O(n*log(n))
+func Of(x ...uint64) *Set { return build(append([]uint64(nil), x...)) }
+
+// Create a new set of the integers from lo up to but not including hi.
+// O((hi-lo)/65536)
+func Range(lo, hi uint64) *Set {
+	xs := &Set{}
+	for lo < hi {
+		key, start := lo>>16, uint16(lo)
+		last := uint16(1<<16 - 1)
+		if (hi-1)>>16 == key {
+			last = uint16(hi - 1)
+		}
+		c := &runContainer{[]run{{start, last}}, int(last) - int(start) + 1}
+		xs.chunks = append(xs.chunks, chunk{key, c})
+		xs.n += c.n
+		lo = (key + 1) << 16
+		if lo == 0 {
+			break // wrapped around
+		}
+	}
+	return xs
+}
+
+// A Seq of uint64 values in ascending order, implemented as a
+// compressed bitmap, which may be empty
+type Set struct {
+	chunks []chunk // in order of key
+	n      int
+}
+
+// Cardinality returns the number of values in the set. O(1)
+func (xs *Set) Cardinality() uint64 { return uint64(xs.n) }
+
+// Union returns the values that are in either set, sharing the chunks
+// that only one of them has. O(n+m) in chunks, faster still for chunks
+// of runs
+func (xs *Set) Union(ys *Set) *Set {
+	switch {
+	case ys.n == 0:
+		return xs
+	case xs.n == 0:
+		return ys
+	}
+	result := &Set{make([]chunk, 0, len(xs.chunks)+len(ys.chunks)), 0}
+	a, b := xs.chunks, ys.chunks
+	for len(a) > 0 || len(b) > 0 {
+		var ch chunk
+		switch {
+		case len(b) == 0 || len(a) > 0 && a[0].key < b[0].key:
+			ch, a = a[0], a[1:]
+		case len(a) == 0 || b[0].key < a[0].key:
+			ch, b = b[0], b[1:]
+		default:
+			ch = chunk{a[0].key, union(a[0].c, b[0].c)}
+			a, b = a[1:], b[1:]
+		}
+		result.chunks = append(result.chunks, ch)
+		result.n += ch.c.card()
+	}
+	return result
+}
+
+// Intersect returns the values that are in both sets. O(n+m) in chunks
+func (xs *Set) Intersect(ys *Set) *Set {
+	result := &Set{}
+	a, b := xs.chunks, ys.chunks
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0].key < b[0].key:
+			a = a[1:]
+		case b[0].key < a[0].key:
+			b = b[1:]
+		default:
+			if c := intersect(a[0].c, b[0].c); c != nil {
+				result.chunks = append(result.chunks, chunk{a[0].key, c})
+				result.n += c.card()
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	return result
+}
+
+// SizeInBytes estimates the memory used by the set, not counting what
+// it shares with other sets. O(chunks)
+func (xs *Set) SizeInBytes() int {
+	size := 0
+	for _, ch := range xs.chunks {
+		size += 32 + ch.c.bytes()
+	}
+	return size
+}
+
+// Everything below here is private
+
+type chunk struct {
+	key uint64 // the high 48 bits shared by the values
+	c   container
+}
+
+// Convert an integer item to a value, panicking if it is not one
+func value(x interface{}) uint64 {
+	v, ok := toValue(x)
+	if !ok {
+		panic(fmt.Sprintf("intset: %T item %v is not a non-negative integer", x, x))
+	}
+	return v
+}
+
+func toValue(x interface{}) (uint64, bool) {
+	switch x := x.(type) {
+	case uint64:
+		return x, true
+	case uint32:
+		return uint64(x), true
+	case uint:
+		return uint64(x), true
+	case uint16:
+		return uint64(x), true
+	case uint8:
+		return uint64(x), true
+	case int:
+		return uint64(x), x >= 0
+	case int64:
+		return uint64(x), x >= 0
+	case int32:
+		return uint64(x), x >= 0
+	case int16:
+		return uint64(x), x >= 0
+	case int8:
+		return uint64(x), x >= 0
+	}
+	return 0, false
+}
+
+// Build a set from values in any order, which may be reordered.
+// O(n*log(n))
+func build(xs []uint64) *Set {
+	sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+	result := &Set{}
+	for len(xs) > 0 {
+		key := xs[0] >> 16
+		lows := []uint16{}
+		for len(xs) > 0 && xs[0]>>16 == key {
+			if n := len(lows); n == 0 || lows[n-1] != uint16(xs[0]) {
+				lows = append(lows, uint16(xs[0]))
+			}
+			xs = xs[1:]
+		}
+		c := optimize(&arrayContainer{lows})
+		result.chunks = append(result.chunks, chunk{key, c})
+		result.n += c.card()
+	}
+	return result
+}
+
+// Index of the chunk with the key, or where it would go
+func (xs *Set) search(key uint64) (int, bool) {
+	i := sort.Search(len(xs.chunks), func(i int) bool { return xs.chunks[i].key >= key })
+	return i, i < len(xs.chunks) && xs.chunks[i].key == key
+}
+
+// Return the set with the container of the ith chunk replaced by c, or
+// without the chunk if c is nil
+func (xs *Set) with(i int, c container) *Set {
+	chunks := append([]chunk(nil), xs.chunks[:i]...)
+	n := xs.n - xs.chunks[i].c.card()
+	if c != nil {
+		chunks = append(chunks, chunk{xs.chunks[i].key, c})
+		n += c.card()
+	}
+	return &Set{append(chunks, xs.chunks[i+1:]...), n}
+}
+
+// O(chunks), copying one container
+func (xs *Set) add(v uint64) *Set {
+	key, low := v>>16, uint16(v)
+	i, ok := xs.search(key)
+	if !ok {
+		chunks := make([]chunk, 0, len(xs.chunks)+1)
+		chunks = append(append(chunks, xs.chunks[:i]...), chunk{key, &arrayContainer{[]uint16{low}}})
+		return &Set{append(chunks, xs.chunks[i:]...), xs.n + 1}
+	}
+	c := xs.chunks[i].c.add(low)
+	if c == xs.chunks[i].c {
+		return xs
+	}
+	return xs.with(i, c)
+}
+
+// O(1)
+func (xs *Set) Len() int { return xs.n }
+
+// O(chunks)
+func (xs *Set) Get(i int) (interface{}, bool) {
+	if i < 0 || i >= xs.n {
+		return nil, false
+	}
+	for _, ch := range xs.chunks {
+		n := ch.c.card()
+		if i < n {
+			return ch.key<<16 | uint64(ch.c.nth(i)), true
+		}
+		i -= n
+	}
+	panic("cannot happen")
+}
+
+// O(log(chunks)+log(chunk))
+func (xs *Set) Contains(x interface{}) bool {
+	v, ok := toValue(x)
+	if !ok {
+		return false
+	}
+	i, ok := xs.search(v >> 16)
+	return ok && xs.chunks[i].c.contains(uint16(v))
+}
+
+// O(1)
+func (xs *Set) Front() interface{} {
+	if xs.n == 0 {
+		panic("getting Front of empty seq")
+	}
+	ch := xs.chunks[0]
+	return ch.key<<16 | uint64(ch.c.nth(0))
+}
+
+// O(1) for arrays and runs, O(words) for bitmaps
+func (xs *Set) Back() interface{} {
+	if xs.n == 0 {
+		panic("getting Back of empty seq")
+	}
+	ch := xs.chunks[len(xs.chunks)-1]
+	return ch.key<<16 | uint64(ch.c.nth(ch.c.card()-1))
+}
+
+// O(chunks), copying one container
+func (xs *Set) Rest() immut.Seq {
+	if xs.n == 0 {
+		panic("getting Rest of empty seq")
+	}
+	return xs.Remove(xs.Front())
+}
+
+// O(1)
+func (xs *Set) IsEmpty() bool { return xs.n == 0 }
+
+// O(n)
+func (xs *Set) Do(f func(interface{})) {
+	xs.Forall(func(x interface{}) bool {
+		f(x)
+		return true
+	})
+}
+
+// O(n)
+func (xs *Set) DoBackwards(f func(interface{})) {
+	for i := len(xs.chunks) - 1; i >= 0; i-- {
+		high := xs.chunks[i].key << 16
+		lows := toArray(xs.chunks[i].c).xs
+		for j := len(lows) - 1; j >= 0; j-- {
+			f(high | uint64(lows[j]))
+		}
+	}
+}
+
+// O(n)
+func (xs *Set) Join(sep string, out io.Writer) {
+	s := ""
+	xs.Do(func(x interface{}) {
+		fmt.Fprintf(out, "%s%v", s, x)
+		s = sep
+	})
+}
+
+// Cannot reverse a sorted set, so just return the set itself
+func (xs *Set) Reverse() immut.Seq { return xs }
+
+// Kept sorted, so an immut.Set
+func (xs *Set) IsSet() {}
+
+// O(chunks), copying one container
+func (xs *Set) AddFront(x interface{}) immut.Seq { return xs.add(value(x)) }
+
+// O(chunks), copying one container
+func (xs *Set) AddBack(x interface{}) immut.Seq { return xs.add(value(x)) }
+
+// O(n+m), or the same as Union if that is a Set
+func (xs *Set) AddAll(that immut.Seq) immut.Seq {
+	ys, ok := that.(*Set)
+	if !ok {
+		vs := make([]uint64, 0, that.Len())
+		that.Do(func(x interface{}) { vs = append(vs, value(x)) })
+		ys = build(vs)
+	}
+	return xs.Union(ys)
+}
+
+// O(n), visiting values in order
+func (xs *Set) Forall(f func(interface{}) bool) bool {
+	for _, ch := range xs.chunks {
+		high := ch.key << 16
+		if !ch.c.forall(func(x uint16) bool { return f(high | uint64(x)) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// O(n*log(n)), panicking if f returns something that is not a
+// non-negative integer
+func (xs *Set) Map(f func(interface{}) interface{}) immut.Seq {
+	vs := make([]uint64, 0, xs.n)
+	xs.Do(func(x interface{}) { vs = append(vs, value(f(x))) })
+	return build(vs)
+}
+
+// O(n), sharing the chunks where nothing is filtered out
+func (xs *Set) Filter(f func(interface{}) bool) immut.Seq {
+	result := &Set{}
+	changed := false
+	for _, ch := range xs.chunks {
+		high := ch.key << 16
+		lows := make([]uint16, 0, ch.c.card())
+		ch.c.forall(func(x uint16) bool {
+			if f(high | uint64(x)) {
+				lows = append(lows, x)
+			}
+			return true
+		})
+		c := ch.c
+		if len(lows) < c.card() {
+			changed = true
+			if len(lows) == 0 {
+				continue
+			}
+			c = optimize(&arrayContainer{lows})
+		}
+		result.chunks = append(result.chunks, chunk{ch.key, c})
+		result.n += c.card()
+	}
+	if !changed {
+		return xs
+	}
+	return result
+}
+
+// O(chunks), copying one container
+func (xs *Set) Remove(x interface{}) immut.Seq {
+	v, ok := toValue(x)
+	if !ok {
+		return xs
+	}
+	i, ok := xs.search(v >> 16)
+	if !ok {
+		return xs
+	}
+	c := xs.chunks[i].c.remove(uint16(v))
+	if c == xs.chunks[i].c {
+		return xs
+	}
+	return xs.with(i, c)
+}
+
+// O(n)
+func (xs *Set) Items() []interface{} {
+	ys := make([]interface{}, 0, xs.n)
+	xs.Do(func(x interface{}) { ys = append(ys, x) })
+	return ys
+}
+
+// Split into the values before and after some point, sharing the
+// chunks. O(chunks), or O(chunk) if there is only one
+func (xs *Set) Split() (immut.Seq, immut.Seq) {
+	if len(xs.chunks) == 1 {
+		ch := xs.chunks[0]
+		lows := toArray(ch.c).xs
+		half := len(lows) / 2
+		front := optimize(&arrayContainer{lows[:half]})
+		back := optimize(&arrayContainer{lows[half:]})
+		return &Set{[]chunk{{ch.key, front}}, front.card()},
+			&Set{[]chunk{{ch.key, back}}, back.card()}
+	}
+	half := len(xs.chunks) / 2
+	front := &Set{xs.chunks[:half:half], 0}
+	for _, ch := range front.chunks {
+		front.n += ch.c.card()
+	}
+	return front, &Set{xs.chunks[half:], xs.n - front.n}
+}
+
+// Diff returns the values removed and added between xs and a later
+// version, without looking inside the chunks the two versions share.
+// O(d) for versions that differ by d chunks and share the rest
+func (xs *Set) Diff(newer immut.Seq) (d immut.Delta) {
+	ys, ok := newer.(*Set)
+	if !ok {
+		xs.Do(func(x interface{}) {
+			if !newer.Contains(x) {
+				d.Removed = append(d.Removed, x)
+			}
+		})
+		newer.Do(func(x interface{}) {
+			if !xs.Contains(x) {
+				d.Added = append(d.Added, x)
+			}
+		})
+		return
+	}
+	a, b := xs.chunks, ys.chunks
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && a[0].key < b[0].key:
+			d.Removed = appendChunk(d.Removed, a[0], nil)
+			a = a[1:]
+		case len(a) == 0 || b[0].key < a[0].key:
+			d.Added = appendChunk(d.Added, b[0], nil)
+			b = b[1:]
+		default:
+			if a[0].c != b[0].c {
+				d.Removed = appendChunk(d.Removed, a[0], b[0].c)
+				d.Added = appendChunk(d.Added, b[0], a[0].c)
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	return
+}
+
+// Append the values of the chunk that are not in other, which may be nil
+func appendChunk(ys []interface{}, ch chunk, other container) []interface{} {
+	ch.c.forall(func(x uint16) bool {
+		if other == nil || !other.contains(x) {
+			ys = append(ys, ch.key<<16|uint64(x))
+		}
+		return true
+	})
+	return ys
+}
+
+func (xs *Set) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	xs.Join(",", &buf)
+	buf.WriteString("}")
+	return buf.String()
+}
@@ -0,0 +1,78 @@
+package lens_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/lens"
+	"github.com/eobrain/immut/list"
+	"github.com/eobrain/immut/unordered"
+	"github.com/eobrain/immut/vector"
+)
+
+func Example() {
+	order := unordered.NewMap("lines", list.New(
+		unordered.NewMap("item", "pen", "price", 2),
+		unordered.NewMap("item", "ink", "price", 5)))
+
+	prices := lens.ComposeTraversal(
+		lens.ComposeTraversal(lens.Key("lines").AsTraversal(), lens.Each()),
+		lens.Key("price").AsTraversal())
+
+	raised := prices.Modify(order, func(p interface{}) interface{} {
+		return p.(int) + 1
+	})
+	fmt.Println(prices.ToSlice(order))
+	fmt.Println(prices.ToSlice(raised))
+	// Output:
+	// [2 5]
+	// [3 6]
+}
+
+func ExampleCompose() {
+	type point struct{ X, Y int }
+	type line struct{ From, To point }
+	to := lens.Lens[line, point]{
+		func(l line) point { return l.To },
+		func(l line, p point) line { l.To = p; return l },
+	}
+	y := lens.Lens[point, int]{
+		func(p point) int { return p.Y },
+		func(p point, y int) point { p.Y = y; return p },
+	}
+	toY := lens.Compose(to, y)
+	l := line{point{0, 0}, point{3, 4}}
+	fmt.Println(toY.Get(l), toY.Set(l, 9), l)
+	// Output:
+	// 4 {{0 0} {3 9}} {{0 0} {3 4}}
+}
+
+func ExampleComposePrism() {
+	grid := vector.New(vector.New(1, 2), vector.New(3, 4))
+	cell := lens.ComposePrism(lens.Index(1), lens.Index(0))
+	fmt.Println(cell.Preview(grid))
+	fmt.Println(cell.Set(grid, 30))
+
+	missing := lens.ComposePrism(lens.Index(5), lens.Index(0))
+	fmt.Println(missing.Preview(grid))
+	fmt.Println(missing.Set(grid, 30))
+
+	byInt := unordered.NewMap(0, "zero")
+	fmt.Println(lens.Index(0).Preview(byInt))
+	// Output:
+	// 3 true
+	// [[1,2],[30,4]]
+	// <nil> false
+	// [[1,2],[3,4]]
+	// <nil> false
+}
+
+func ExampleFiltered() {
+	xs := list.New(1, 20, 3, 40)
+	big := lens.ComposeTraversal(lens.Each(),
+		lens.Filtered(func(x interface{}) bool { return x.(int) >= 10 }))
+	fmt.Println(big.ToSlice(xs))
+	fmt.Println(big.Set(xs, 0).(immut.Seq))
+	// Output:
+	// [20 40]
+	// [1,0,3,0]
+}
@@ -0,0 +1,213 @@
+// The lens package provides composable accessors for reading and
+// updating parts of immutable values, including items nested inside
+// immut.Seq and immut.Map collections.  A Lens focuses on exactly one
+// part, a Prism on a part that may be absent, and a Traversal on any
+// number of parts.  Setting through any of them returns a new whole,
+// leaving the old one unchanged.
+package lens
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "github.com/eobrain/immut"
+
+// A Lens focuses on one part A of a whole S.
+type Lens[S, A any] struct {
+	Get func(S) A
+	Set func(S, A) S
+}
+
+// A Prism focuses on a part A of a whole S that may be absent.  Set
+// returns the whole unchanged if the part is absent.
+type Prism[S, A any] struct {
+	Preview func(S) (A, bool)
+	Set     func(S, A) S
+}
+
+// A Traversal focuses on any number of parts A of a whole S.  Fold calls
+// a function on each part in turn, and Modify returns a new whole with
+// each part replaced by the result of a function on it.
+type Traversal[S, A any] struct {
+	Fold   func(S, func(A))
+	Modify func(S, func(A) A) S
+}
+
+// Modify returns s with its part replaced by f of the part.
+func (l Lens[S, A]) Modify(s S, f func(A) A) S { return l.Set(s, f(l.Get(s))) }
+
+// AsPrism returns l as a Prism, so that it can be composed with Prisms.
+func (l Lens[S, A]) AsPrism() Prism[S, A] {
+	return Prism[S, A]{
+		func(s S) (A, bool) { return l.Get(s), true },
+		l.Set,
+	}
+}
+
+// AsTraversal returns l as a Traversal, so that it can be composed with
+// Traversals.
+func (l Lens[S, A]) AsTraversal() Traversal[S, A] { return l.AsPrism().AsTraversal() }
+
+// Modify returns s with its part replaced by f of the part, or s itself
+// if the part is absent.
+func (p Prism[S, A]) Modify(s S, f func(A) A) S {
+	if a, ok := p.Preview(s); ok {
+		return p.Set(s, f(a))
+	}
+	return s
+}
+
+// AsTraversal returns p as a Traversal of zero or one parts, so that it
+// can be composed with Traversals.
+func (p Prism[S, A]) AsTraversal() Traversal[S, A] {
+	return Traversal[S, A]{
+		func(s S, f func(A)) {
+			if a, ok := p.Preview(s); ok {
+				f(a)
+			}
+		},
+		p.Modify,
+	}
+}
+
+// ToSlice returns the parts of s in a new slice.
+func (t Traversal[S, A]) ToSlice(s S) (as []A) {
+	t.Fold(s, func(a A) { as = append(as, a) })
+	return
+}
+
+// Set returns s with every part replaced by a.
+func (t Traversal[S, A]) Set(s S, a A) S {
+	return t.Modify(s, func(A) A { return a })
+}
+
+// Compose returns a Lens focusing on the part B of the part A of S.
+func Compose[S, A, B any](outer Lens[S, A], inner Lens[A, B]) Lens[S, B] {
+	return Lens[S, B]{
+		func(s S) B { return inner.Get(outer.Get(s)) },
+		func(s S, b B) S { return outer.Set(s, inner.Set(outer.Get(s), b)) },
+	}
+}
+
+// ComposePrism returns a Prism focusing on the part B of the part A of
+// S, present only if both parts are present.
+func ComposePrism[S, A, B any](outer Prism[S, A], inner Prism[A, B]) Prism[S, B] {
+	return Prism[S, B]{
+		func(s S) (b B, ok bool) {
+			if a, ok := outer.Preview(s); ok {
+				return inner.Preview(a)
+			}
+			return
+		},
+		func(s S, b B) S {
+			return outer.Modify(s, func(a A) A { return inner.Set(a, b) })
+		},
+	}
+}
+
+// ComposeTraversal returns a Traversal focusing on every part B of
+// every part A of S.
+func ComposeTraversal[S, A, B any](outer Traversal[S, A], inner Traversal[A, B]) Traversal[S, B] {
+	return Traversal[S, B]{
+		func(s S, f func(B)) {
+			outer.Fold(s, func(a A) { inner.Fold(a, f) })
+		},
+		func(s S, f func(B) B) S {
+			return outer.Modify(s, func(a A) A { return inner.Modify(a, f) })
+		},
+	}
+}
+
+// Index returns a Prism focusing on the item at index i of an immut.Seq,
+// absent if the whole is not a Seq or i is out of range.  Setting the
+// item of an immut.Set leaves it unchanged, as its items have no
+// positions to edit.
+func Index(i int) Prism[interface{}, interface{}] {
+	return at(i, func(s interface{}) bool {
+		_, ok := s.(immut.Seq)
+		return ok
+	})
+}
+
+// Key returns a Prism focusing on the value of key k in an immut.Map,
+// absent if the whole is not a Map or k is not in it.
+func Key(k interface{}) Prism[interface{}, interface{}] {
+	return at(k, func(s interface{}) bool {
+		_, ok := s.(immut.Map)
+		return ok
+	})
+}
+
+// Each returns a Traversal focusing on every item of an immut.Seq, or
+// on nothing if the whole is not a Seq.
+func Each() Traversal[interface{}, interface{}] {
+	return Traversal[interface{}, interface{}]{
+		func(s interface{}, f func(interface{})) {
+			if xs, ok := s.(immut.Seq); ok {
+				xs.Do(f)
+			}
+		},
+		func(s interface{}, f func(interface{}) interface{}) interface{} {
+			if xs, ok := s.(immut.Seq); ok {
+				return xs.Map(f)
+			}
+			return s
+		},
+	}
+}
+
+// Filtered returns a Traversal focusing on the whole if pred is true of
+// it, or else on nothing.  Compose it after Each to focus on only some
+// items.
+func Filtered[A any](pred func(A) bool) Traversal[A, A] {
+	return Traversal[A, A]{
+		func(a A, f func(A)) {
+			if pred(a) {
+				f(a)
+			}
+		},
+		func(a A, f func(A) A) A {
+			if pred(a) {
+				return f(a)
+			}
+			return a
+		},
+	}
+}
+
+// Everything below here is private
+
+func at(key interface{}, is func(s interface{}) bool) Prism[interface{}, interface{}] {
+	return Prism[interface{}, interface{}]{
+		func(s interface{}) (interface{}, bool) {
+			if !is(s) {
+				return nil, false
+			}
+			x, err := immut.GetIn(s, key)
+			return x, err == nil
+		},
+		func(s interface{}, x interface{}) interface{} {
+			if !is(s) {
+				return s
+			}
+			if _, err := immut.GetIn(s, key); err != nil {
+				return s
+			}
+			t, err := immut.AssocIn(s, []interface{}{key}, x)
+			if err != nil {
+				return s
+			}
+			return t
+		},
+	}
+}
@@ -16,58 +16,112 @@ package list
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/eobrain/immut"
 	"io"
 )
 
-// Create a new list containing the arguments.
+// Create a new list containing the arguments. O(n)
 func New(item ...interface{}) immut.Seq {
-	if len(item) == 0 {
-		return empty{}
-	}
-	return &cons{item[0], New(item[1:]...)}
+	return build(item, empty{})
 }
 
 // Create a new list containing n repeats of x
 func Repeat(n int, x interface{}) (result immut.Seq) {
 	result = empty{}
 	for i := 0; i < n; i++ {
-		result = &cons{x, result}
+		result = &cons{x, result, i + 1}
 	}
 	return result
 }
 
+// Create a new list with x in front of rest, sharing rest.  O(1) if rest
+// is a list or vector.
+func Cons(x interface{}, rest immut.Seq) immut.Seq {
+	return &cons{x, rest, 1 + rest.Len()}
+}
+
+// Create a new list containing the items received from ch until it is
+// closed, or return the context's error if ctx is done first.
+func FromChan(ctx context.Context, ch <-chan interface{}) (immut.Seq, error) {
+	items, err := immut.Collect(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	return New(items...), nil
+}
+
 // Everything below here is private
 
+// All operations loop over the cells rather than recursing, so that
+// long lists do not need deep stacks.  The rest of the last cell is
+// usually empty{}, but may be a Seq of another kind joined on by AddAll
+// or Cons, which is called on to do its part.
 type cons struct {
 	first interface{}
 	rest  immut.Seq
+	len   int
 }
 type empty struct{}
 
-// O(n)
-func (xs *cons) Len() int {
-	return 1 + xs.rest.Len()
+// Return a list of the items followed by tail, sharing tail. O(n)
+func build(items []interface{}, tail immut.Seq) immut.Seq {
+	n := tail.Len()
+	for i := len(items) - 1; i >= 0; i-- {
+		n++
+		tail = &cons{items[i], tail, n}
+	}
+	return tail
 }
-func (empty) Len() int { return 0 }
 
-// O(n)
-func (xs *cons) Get(i int) (interface{}, bool) {
-	if i == 0 {
-		return xs.Front(), true
+// Return the cells of the list and the Seq after the last of them. O(n)
+func (xs *cons) cells() (cells []*cons, tail immut.Seq) {
+	cells = make([]*cons, 0, xs.len)
+	for tail = xs; ; {
+		c, ok := tail.(*cons)
+		if !ok {
+			return
+		}
+		cells = append(cells, c)
+		tail = c.rest
 	}
-	if i < 0 {
+}
+
+// O(1)
+func (xs *cons) Len() int { return xs.len }
+func (empty) Len() int    { return 0 }
+
+// O(i)
+func (xs *cons) Get(i int) (interface{}, bool) {
+	if i < 0 || i >= xs.len {
 		return nil, false
 	}
-	return xs.rest.Get(i - 1)
+	var ys immut.Seq = xs
+	for ; i > 0; i-- {
+		c, ok := ys.(*cons)
+		if !ok {
+			return ys.Get(i)
+		}
+		ys = c.rest
+	}
+	return ys.Front(), true
 }
 func (empty) Get(i int) (interface{}, bool) { return nil, false }
 
 // O(n)
 func (xs *cons) Contains(x interface{}) bool {
-	return xs.first == x || xs.rest.Contains(x)
-	//TODO make this tail recursive
+	var ys immut.Seq = xs
+	for {
+		c, ok := ys.(*cons)
+		if !ok {
+			return ys.Contains(x)
+		}
+		if c.first == x {
+			return true
+		}
+		ys = c.rest
+	}
 }
 func (empty) Contains(interface{}) bool { return false }
 
@@ -77,10 +131,17 @@ func (empty) Front() interface{}    { panic("getting Front of empty seq") }
 
 // O(n)
 func (xs *cons) Back() interface{} {
-	if xs.rest.IsEmpty() {
-		return xs.first
+	c := xs
+	for {
+		next, ok := c.rest.(*cons)
+		if !ok {
+			if c.rest.IsEmpty() {
+				return c.first
+			}
+			return c.rest.Back()
+		}
+		c = next
 	}
-	return xs.rest.Back()
 }
 func (empty) Back() interface{} { panic("getting Back of empty seq") }
 
@@ -94,66 +155,134 @@ func (empty) IsEmpty() bool    { return true }
 
 // O(n)
 func (xs *cons) Do(f func(interface{})) {
-	f(xs.first)
-	xs.rest.Do(f) //recursion
+	var ys immut.Seq = xs
+	for {
+		c, ok := ys.(*cons)
+		if !ok {
+			ys.Do(f)
+			return
+		}
+		f(c.first)
+		ys = c.rest
+	}
 }
 func (empty) Do(f func(interface{})) {}
 
-// Do backwards
+// O(n)
 func (xs *cons) DoBackwards(f func(interface{})) {
-	xs.rest.DoBackwards(f)
-	f(xs.first)
+	cells, tail := xs.cells()
+	tail.DoBackwards(f)
+	for i := len(cells) - 1; i >= 0; i-- {
+		f(cells[i].first)
+	}
 }
 func (empty) DoBackwards(f func(interface{})) {}
 
 // O(n)
 func (xs *cons) Join(sep string, out io.Writer) {
 	fmt.Fprintf(out, "%v", xs.first)
-	if !xs.rest.IsEmpty() {
+	ys := xs.rest
+	for {
+		if ys.IsEmpty() {
+			return
+		}
 		fmt.Fprint(out, sep)
-		xs.rest.Join(sep, out)
+		c, ok := ys.(*cons)
+		if !ok {
+			ys.Join(sep, out)
+			return
+		}
+		fmt.Fprintf(out, "%v", c.first)
+		ys = c.rest
 	}
 }
 func (empty) Join(string, io.Writer) {}
 
+// O(n)
 func (xs *cons) Reverse() immut.Seq {
-	return xs.rest.Reverse().AddBack(xs.first)
+	var result immut.Seq = empty{}
+	xs.Do(func(x interface{}) {
+		result = &cons{x, result, result.Len() + 1}
+	})
+	return result
 }
 func (n empty) Reverse() immut.Seq { return n }
 
 // O(1)
-func (xs *cons) AddFront(x interface{}) immut.Seq { return &cons{x, xs} }
+func (xs *cons) AddFront(x interface{}) immut.Seq { return &cons{x, xs, xs.len + 1} }
 func (empty) AddFront(item interface{}) immut.Seq { return New(item) }
 
 // O(n)
 func (xs *cons) AddBack(x interface{}) immut.Seq {
-	return &cons{xs.first, xs.rest.AddBack(x)}
+	cells, tail := xs.cells()
+	return rebuild(cells, tail.AddBack(x))
 }
 func (n empty) AddBack(item interface{}) immut.Seq { return New(item) }
 
+// O(n), sharing that
 func (xs *cons) AddAll(that immut.Seq) immut.Seq {
-	if xs.rest.IsEmpty() {
-		return &cons{xs.first, that}
-	}
-	return &cons{xs.first, xs.rest.AddAll(that)}
+	cells, tail := xs.cells()
+	return rebuild(cells, tail.AddAll(that))
 }
 func (n empty) AddAll(other immut.Seq) immut.Seq { return other }
 
+// O(n)
 func (xs *cons) Forall(f func(interface{}) bool) bool {
-	return f(xs.first) && xs.rest.Forall(f)
+	var ys immut.Seq = xs
+	for {
+		c, ok := ys.(*cons)
+		if !ok {
+			return ys.Forall(f)
+		}
+		if !f(c.first) {
+			return false
+		}
+		ys = c.rest
+	}
 }
 func (empty) Forall(f func(interface{}) bool) bool { return true }
 
+// O(n), sharing the end of the list if f does not change it
 func (xs *cons) Map(f func(interface{}) interface{}) immut.Seq {
-	return &cons{f(xs.first), xs.rest.Map(f)}
+	cells, tail := xs.cells()
+	ys := make([]interface{}, len(cells))
+	for i, c := range cells {
+		ys[i] = f(c.first)
+	}
+	result := tail.Map(f)
+	if !immut.Identical(result, tail) {
+		return build(ys, result)
+	}
+	n := len(cells)
+	for n > 0 && immut.Identical(ys[n-1], cells[n-1].first) {
+		n--
+	}
+	return build(ys[:n], shared(cells, n, tail))
 }
 func (n empty) Map(f func(interface{}) interface{}) immut.Seq { return n }
 
+// O(n), sharing the end of the list if none of it is filtered out
 func (xs *cons) Filter(f func(interface{}) bool) immut.Seq {
-	if f(xs.first) {
-		return &cons{xs.first, xs.rest.Filter(f)}
+	cells, tail := xs.cells()
+	keep := make([]bool, len(cells))
+	for i, c := range cells {
+		keep[i] = f(c.first)
 	}
-	return xs.rest.Filter(f)
+	result := tail.Filter(f)
+	n := len(cells)
+	if immut.Identical(result, tail) {
+		for n > 0 && keep[n-1] {
+			n--
+		}
+		result = shared(cells, n, tail)
+	}
+	var ys []interface{}
+	for i, c := range cells[:n] {
+		if keep[i] {
+			ys = append(ys, c.first)
+		}
+	}
+	return build(ys, result)
 }
 func (n empty) Filter(f func(interface{}) bool) immut.Seq { return n }
 
@@ -166,27 +295,48 @@ func (xs *cons) String() string {
 }
 func (empty) String() string { return "[]" }
 
-func (xs *cons) Remove(match interface{}) (result immut.Seq) {
-	if xs.first == match {
-		result = xs.rest.Remove(match)
-	} else {
-		if xs.rest.Contains(match) {
-			result = &cons{xs.first, xs.rest.Remove(match)}
-		} else {
-			result = xs
-		}
+// Split into the first half, which is copied, and the second half, which
+// is shared. O(n)
+func (xs *cons) Split() (immut.Seq, immut.Seq) {
+	h := xs.len / 2
+	front := make([]interface{}, h)
+	var back immut.Seq = xs
+	for i := range front {
+		front[i] = back.Front()
+		back = back.Rest()
 	}
-	return
+	return New(front...), back
+}
+
+// O(n), sharing the end of the list after the last match
+func (xs *cons) Remove(match interface{}) immut.Seq {
+	return xs.Filter(func(x interface{}) bool { return x != match })
 }
 func (n empty) Remove(x interface{}) immut.Seq { return n }
 
 func (xs *cons) Items() (ys []interface{}) {
-	ys = make([]interface{}, xs.Len())
-	i := 0
+	ys = make([]interface{}, 0, xs.len)
 	xs.Do(func(x interface{}) {
-		ys[i] = x
-		i++
+		ys = append(ys, x)
 	})
 	return
 }
 func (empty) Items() []interface{} { return []interface{}{} }
+
+// Return a list of the firsts of the cells followed by tail.
+func rebuild(cells []*cons, tail immut.Seq) immut.Seq {
+	items := make([]interface{}, len(cells))
+	for i, c := range cells {
+		items[i] = c.first
+	}
+	return build(items, tail)
+}
+
+// Return the part of the list starting at cells[i], or tail if i is past
+// the last cell.
+func shared(cells []*cons, i int, tail immut.Seq) immut.Seq {
+	if i < len(cells) {
+		return cells[i]
+	}
+	return tail
+}
@@ -0,0 +1,237 @@
+package ordered
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"sort"
+)
+
+// The trees are weight-balanced (Adams 1993, with the parameters of
+// Hirai and Yamamoto 2011): the weight of one subtree, its size plus
+// one, is never more than delta times the weight of its sibling.  Bulk
+// operations are built from join and split, as in Blelloch, Ferizovic
+// and Sun 2016.
+
+const (
+	delta = 3
+	gamma = 2
+)
+
+func weight(xs immut.Seq) int { return xs.Len() + 1 }
+
+// Return a tree of x between left and right, which were balanced
+// before one of them gained or lost an item. O(1)
+func balance(x interface{}, xS string, left, right immut.Seq) *Tree {
+	switch {
+	case delta*weight(left) < weight(right):
+		r := right.(*Tree)
+		if weight(r.left) < gamma*weight(r.right) {
+			// single left rotation
+			return tree(r.value, r.valueS, tree(x, xS, left, r.left), r.right)
+		}
+		// double left rotation
+		rl := r.left.(*Tree)
+		return tree(rl.value, rl.valueS,
+			tree(x, xS, left, rl.left),
+			tree(r.value, r.valueS, rl.right, r.right))
+	case delta*weight(right) < weight(left):
+		l := left.(*Tree)
+		if weight(l.right) < gamma*weight(l.left) {
+			// single right rotation
+			return tree(l.value, l.valueS, l.left, tree(x, xS, l.right, right))
+		}
+		// double right rotation
+		lr := l.right.(*Tree)
+		return tree(lr.value, lr.valueS,
+			tree(l.value, l.valueS, l.left, lr.left),
+			tree(x, xS, lr.right, right))
+	}
+	return tree(x, xS, left, right)
+}
+
+// Return a tree of x between left and right, which may differ in size
+// by any amount. O(log(n/m)) where m is the size of the smaller one
+func join(left immut.Seq, x interface{}, xS string, right immut.Seq) *Tree {
+	switch {
+	case delta*weight(left) < weight(right):
+		r := right.(*Tree)
+		return balance(r.value, r.valueS, join(left, x, xS, r.left), r.right)
+	case delta*weight(right) < weight(left):
+		l := left.(*Tree)
+		return balance(l.value, l.valueS, l.left, join(l.right, x, xS, right))
+	}
+	return tree(x, xS, left, right)
+}
+
+// Return a tree of everything in left followed by everything in right.
+// O(log n)
+func merge(left, right immut.Seq) immut.Seq {
+	switch {
+	case left.IsEmpty():
+		return right
+	case right.IsEmpty():
+		return left
+	case delta*weight(left) < weight(right):
+		r := right.(*Tree)
+		return balance(r.value, r.valueS, merge(left, r.left), r.right)
+	case delta*weight(right) < weight(left):
+		l := left.(*Tree)
+		return balance(l.value, l.valueS, l.left, merge(l.right, right))
+	}
+	r := right.(*Tree)
+	min := r.Front()
+	return balance(min, s(min), left, r.Rest())
+}
+
+// Split xs into the items ordered before x and those ordered after it,
+// and whether x itself is there, reusing the subtrees that do not
+// straddle x. O(log n)
+func split(xs immut.Seq, x interface{}, xS string) (left immut.Seq, found bool, right immut.Seq) {
+	t, ok := xs.(*Tree)
+	if !ok {
+		return Empty{}, false, Empty{}
+	}
+	if x == t.value {
+		return t.left, true, t.right
+	}
+	//hack: use string compare for ordering
+	if xS < t.valueS || xS == t.valueS && contains(t.left, x, xS) {
+		left, found, right = split(t.left, x, xS)
+		return left, found, join(right, t.value, t.valueS, t.right)
+	}
+	left, found, right = split(t.right, x, xS)
+	return join(t.left, t.value, t.valueS, left), found, right
+}
+
+// Return the union of two trees. O(m*log(n/m+1)) where m is the size
+// of the smaller one
+func union(xs, ys immut.Seq) immut.Seq {
+	t, ok := ys.(*Tree)
+	switch {
+	case !ok || xs == ys:
+		return xs
+	case xs.IsEmpty():
+		return ys
+	}
+	left, _, right := split(xs, t.value, t.valueS)
+	return join(union(left, t.left), t.value, t.valueS, union(right, t.right))
+}
+
+// Whether x is in xs, whose items have x's string at the given positions
+// only if they are ties, which may be on either side. O(log n)
+func contains(xs immut.Seq, x interface{}, xS string) bool {
+	for {
+		t, ok := xs.(*Tree)
+		if !ok {
+			return false
+		}
+		if x == t.value {
+			return true
+		}
+		switch {
+		case xS < t.valueS:
+			xs = t.left
+		case xS > t.valueS:
+			xs = t.right
+		default:
+			// distinct values with the same string could be either side
+			return contains(t.left, x, xS) || contains(t.right, x, xS)
+		}
+	}
+}
+
+// Return xs without x, or xs itself if x is not in it. O(log n)
+func remove(xs immut.Seq, x interface{}, xS string) immut.Seq {
+	t, ok := xs.(*Tree)
+	if !ok {
+		return xs
+	}
+	if x == t.value {
+		return merge(t.left, t.right)
+	}
+	if xS < t.valueS || xS == t.valueS && contains(t.left, x, xS) {
+		left := remove(t.left, x, xS)
+		if left == t.left {
+			return xs
+		}
+		return balance(t.value, t.valueS, left, t.right)
+	}
+	right := remove(t.right, x, xS)
+	if right == t.right {
+		return xs
+	}
+	return balance(t.value, t.valueS, t.left, right)
+}
+
+// An item and its string, which determines where it goes in the tree
+type entry struct {
+	x  interface{}
+	xS string
+}
+
+func entries(items []interface{}) []entry {
+	es := make([]entry, len(items))
+	for i, x := range items {
+		es[i] = entry{x, s(x)}
+	}
+	return es
+}
+
+func isSorted(es []entry) bool {
+	for i := 1; i < len(es); i++ {
+		if es[i].xS < es[i-1].xS {
+			return false
+		}
+	}
+	return true
+}
+
+// Return sorted entries without duplicates.  Equal items have equal
+// strings, so only runs of entries with the same string need checking.
+func dedupe(es []entry) []entry {
+	result := es[:0:0]
+	run := 0 // start of the run of entries in result with the same string
+	for _, e := range es {
+		if len(result) > 0 && result[len(result)-1].xS != e.xS {
+			run = len(result)
+		}
+		dup := false
+		for _, r := range result[run:] {
+			if r.x == e.x {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Build a perfectly balanced tree from sorted entries. O(n)
+func buildBalanced(es []entry) treeNode {
+	if len(es) == 0 {
+		return Empty{}
+	}
+	mid := len(es) / 2
+	return tree(es[mid].x, es[mid].xS,
+		buildBalanced(es[:mid]), buildBalanced(es[mid+1:]))
+}
+
+func sortEntries(es []entry) {
+	sort.SliceStable(es, func(i, j int) bool { return es[i].xS < es[j].xS })
+}
@@ -0,0 +1,52 @@
+package ordered
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "github.com/eobrain/immut"
+
+// Diff returns the items removed and added between xs and a later
+// version, without looking inside the subtrees the two versions share.
+// O(d*log(n)) for versions that differ by d items and share structure,
+// O(n*log(n)) for unrelated trees.
+func (xs *Tree) Diff(newer immut.Seq) (d immut.Delta) {
+	diff(xs, asTreeNode(newer), &d)
+	return
+}
+func (Empty) Diff(newer immut.Seq) immut.Delta {
+	return immut.Delta{Added: newer.Items()}
+}
+
+// Everything below here is private
+
+func diff(older, newer treeNode, d *immut.Delta) {
+	if older == newer {
+		return
+	}
+	t, ok := newer.(*Tree)
+	if !ok {
+		d.Removed = append(d.Removed, older.Items()...)
+		return
+	}
+	if older.IsEmpty() {
+		d.Added = append(d.Added, newer.Items()...)
+		return
+	}
+	left, found, right := split(older, t.value, t.valueS)
+	diff(asTreeNode(left), asTreeNode(t.left), d)
+	if !found {
+		d.Added = append(d.Added, t.value)
+	}
+	diff(asTreeNode(right), asTreeNode(t.right), d)
+}
@@ -0,0 +1,54 @@
+package ordered_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/ordered"
+)
+
+func ExampleTree_Get() {
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = fmt.Sprintf("item%02d", i)
+	}
+	set := ordered.New(items...)
+	pageSize := 10
+	for page := 3; page < 5; page++ {
+		first, _ := set.Get(page * pageSize)
+		last, _ := set.Get((page+1)*pageSize - 1)
+		fmt.Println(page, first, last)
+	}
+	fmt.Println(set.(*ordered.Tree).Rank("item42"))
+	fmt.Println(set.(*ordered.Tree).Rank("nope"))
+	// Output:
+	// 3 item30 item39
+	// 4 item40 item49
+	// 42 true
+	// 100 false
+}
+
+func ExampleFromSorted() {
+	// keys in order, so no sorting is needed and they go straight
+	// into a balanced tree
+	evens := make([]interface{}, 50000)
+	odds := make([]interface{}, 50000)
+	for i := range evens {
+		evens[i] = fmt.Sprintf("key%06d", 2*i)
+		odds[i] = fmt.Sprintf("key%06d", 2*i+1)
+	}
+	set := ordered.FromSorted(evens...)
+
+	// still balanced after a bulk union and a run of in-order adds
+	all := set.AddAll(ordered.FromSorted(odds...))
+	for i := 100000; i < 100005; i++ {
+		all = all.AddBack(fmt.Sprintf("key%06d", i))
+	}
+	fmt.Println(set.Len(), all.Len())
+	fmt.Println(all.Get(77777))
+	fmt.Println(all.Back())
+	fmt.Println(all.Remove("key000000").Front())
+	// Output:
+	// 50000 100005
+	// key077777 true
+	// key100004
+	// key000001
+}
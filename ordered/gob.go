@@ -0,0 +1,47 @@
+package ordered
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"errors"
+	"github.com/eobrain/immut/internal/gobitems"
+)
+
+func init() {
+	gobitems.Register("ordered.Tree", &Tree{})
+	gobitems.Register("ordered.Empty", Empty{})
+}
+
+// GobEncode writes the items of the tree in order.
+func (xs *Tree) GobEncode() ([]byte, error) { return gobitems.Encode(xs.Items()) }
+
+// GobDecode rebuilds the tree from its items.
+func (xs *Tree) GobDecode(data []byte) error {
+	items, err := gobitems.Decode(data)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return errors.New("ordered: no items to decode into non-empty tree")
+	}
+	*xs = *newTreeNode(items...).(*Tree)
+	return nil
+}
+
+// GobEncode writes nothing, as there is nothing in an empty tree.
+func (Empty) GobEncode() ([]byte, error) { return []byte{}, nil }
+
+// GobDecode reads nothing, as there is nothing in an empty tree.
+func (*Empty) GobDecode([]byte) error { return nil }
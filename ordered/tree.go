@@ -19,33 +19,64 @@ import (
 	"fmt"
 	"github.com/eobrain/immut"
 	"io"
+	"strings"
 )
 
-// Note, no attempt to keep this binary tree balanced
+// The binary trees are kept balanced, so that lookups and updates are
+// O(log n) whatever order items are added in
 
-// Create a new ordered set containing the arguments. O(n*log(n))
+// Create a new ordered set containing the arguments. O(n*log(n)), or
+// O(n) if they are already in order
 func New(item ...interface{}) immut.Seq { return newTreeNode(item...) }
 
+// Create a new ordered set from arguments that are already in order.
+// The same as New, which also skips sorting items already in order, and
+// kept to make that intent explicit. O(n), or O(n*log(n)) if out of order
+func FromSorted(item ...interface{}) immut.Seq { return newTreeNode(item...) }
+
 // A Seq implemented as a binary tree, containing at least one value
 type Tree struct {
 	value  interface{}
 	valueS string //hack: use string compare for ordering
 	left   immut.Seq
 	right  immut.Seq
+	size   int // number of values in this tree
 }
 
 // An empty Seq
 type Empty struct{}
 
+// Create a tree with root value x and the given subtrees, sharing the
+// subtrees rather than copying them where they are balanced.
+// Everything in left must order before x and everything in right after
+// it. O(log n)
+func Node(left immut.Seq, x interface{}, right immut.Seq) *Tree {
+	return join(asTreeNode(left), x, s(x), asTreeNode(right))
+}
+
+// Value returns the value at the root of the tree. O(1)
+func (xs *Tree) Value() interface{} { return xs.value }
+
+// Left returns the subtree of values ordered before the root. O(1)
+func (xs *Tree) Left() immut.Seq { return xs.left }
+
+// Right returns the subtree of values ordered after the root. O(1)
+func (xs *Tree) Right() immut.Seq { return xs.right }
+
+// Compare is the order of the items in ordered sets, which is the order
+// of their %v strings, so that 10 is before 9.  It returns a negative
+// number if a is before b, zero if they are at the same position and a
+// positive number if a is after b.
+func Compare(a, b interface{}) int { return strings.Compare(s(a), s(b)) }
+
 // Everything below here is private
 
 func newTreeNode(item ...interface{}) treeNode {
-	if len(item) == 0 {
-		return Empty{}
+	es := entries(item)
+	if !isSorted(es) {
+		sortEntries(es)
 	}
-	first := item[0]
-	return (&Tree{first, s(first), Empty{}, Empty{}}).buildTreeFrom(
-		item[1:])
+	return buildBalanced(dedupe(es))
 }
 
 // Both Tree and Empty implement this
@@ -54,43 +85,64 @@ type treeNode interface {
 	addTreeNode(x interface{}, itemS string) *Tree
 }
 
-// Recursively build a binary tree. O(n*log(n))
-func (xs *Tree) buildTreeFrom(remaining []interface{}) *Tree {
-	if len(remaining) == 0 {
-		return xs
-	}
-	x := remaining[0]
-	return xs.addTreeNode(x, s(x)).buildTreeFrom(remaining[1:])
+func tree(x interface{}, xS string, left, right immut.Seq) *Tree {
+	return &Tree{x, xS, left, right, 1 + left.Len() + right.Len()}
 }
 
 func s(x interface{}) string { return fmt.Sprintf("%v", x) }
 
-// O(log n)
-func (xs *Tree) Len() int {
-	return 1 + xs.left.Len() + xs.right.Len()
-}
-func (Empty) Len() int { return 0 }
+// O(1)
+func (xs *Tree) Len() int { return xs.size }
+func (Empty) Len() int    { return 0 }
 
-// O(log n)
+// O(log n), without allocating
 func (xs *Tree) Get(i int) (interface{}, bool) {
-	if i == 0 {
-		return xs.Front(), true
-	}
-	if i < 0 {
+	if i < 0 || i >= xs.size {
 		return nil, false
 	}
-	return xs.Rest().Get(i - 1)
+	t := xs
+	for {
+		n := t.left.Len()
+		switch {
+		case i < n:
+			t = t.left.(*Tree)
+		case i == n:
+			return t.value, true
+		default:
+			i -= n + 1
+			t = t.right.(*Tree)
+		}
+	}
 }
 func (Empty) Get(i int) (interface{}, bool) { return nil, false }
 
 // O(log n)
-func (xs *Tree) Contains(x interface{}) bool {
-	itemS := s(x) //inefficiently re-creating on every recursion
-	return x == xs.value ||
-		itemS < xs.valueS && xs.left.Contains(x) ||
-		xs.right.Contains(x)
+func (xs *Tree) Contains(x interface{}) bool { return contains(xs, x, s(x)) }
+func (Empty) Contains(interface{}) bool      { return false }
+
+// Rank returns the index of x in the tree, and whether it is there at
+// all. O(log n)
+func (xs *Tree) Rank(x interface{}) (int, bool) {
+	itemS := s(x)
+	rank := 0
+	var t immut.Seq = xs
+	for {
+		tt, ok := t.(*Tree)
+		if !ok {
+			return rank, false
+		}
+		if x == tt.value {
+			return rank + tt.left.Len(), true
+		}
+		//hack: use string compare for ordering
+		if itemS < tt.valueS || itemS == tt.valueS && contains(tt.left, x, itemS) {
+			t = tt.left
+		} else {
+			rank += tt.left.Len() + 1
+			t = tt.right
+		}
+	}
 }
-func (Empty) Contains(interface{}) bool { return false }
 
 // O(log n)
 func (xs *Tree) Front() interface{} {
@@ -115,7 +167,7 @@ func (xs *Tree) Rest() immut.Seq {
 	if xs.left.IsEmpty() {
 		return xs.right
 	}
-	return &Tree{xs.value, xs.valueS, xs.left.Rest(), xs.right}
+	return balance(xs.value, xs.valueS, xs.left.Rest(), xs.right)
 }
 func (Empty) Rest() immut.Seq {
 	panic("getting Rest of empty seq")
@@ -179,26 +231,38 @@ func (Empty) Join(string, io.Writer) {}
 
 // O(log n)
 func (xs *Tree) addTreeNode(x interface{}, itemS string) *Tree {
-	if x == xs.value {
+	if contains(xs, x, itemS) {
 		//set semantics -- cannnot have more than one of any value
 		return xs
 	}
+	return xs.insert(x, itemS)
+}
+
+// Add x, which is known not to be there already. O(log n)
+func (xs *Tree) insert(x interface{}, itemS string) *Tree {
 	//hack: use string compare for ordering
 	if itemS < xs.valueS {
 		//put on left
-		return &Tree{xs.value,
+		return balance(xs.value,
 			xs.valueS,
-			asTreeNode(xs.left).addTreeNode(x, itemS),
-			xs.right}
+			insert(xs.left, x, itemS),
+			xs.right)
 	}
 	//put on right
-	return &Tree{xs.value,
+	return balance(xs.value,
 		xs.valueS,
 		xs.left,
-		asTreeNode(xs.right).addTreeNode(x, itemS)}
+		insert(xs.right, x, itemS))
+}
+
+func insert(xs immut.Seq, x interface{}, itemS string) *Tree {
+	if t, ok := xs.(*Tree); ok {
+		return t.insert(x, itemS)
+	}
+	return tree(x, itemS, Empty{}, Empty{})
 }
 func (Empty) addTreeNode(item interface{}, itemS string) *Tree {
-	return &Tree{item, itemS, Empty{}, Empty{}}
+	return tree(item, itemS, Empty{}, Empty{})
 }
 
 func asTreeNode(xs immut.Seq) treeNode {
@@ -218,6 +282,10 @@ func asTreeNode(xs immut.Seq) treeNode {
 func (xs *Tree) Reverse() immut.Seq { return xs }
 func (n Empty) Reverse() immut.Seq  { return n }
 
+// Kept sorted, so an immut.Set
+func (xs *Tree) IsSet() {}
+func (Empty) IsSet()    {}
+
 // O(log n)
 func (xs *Tree) AddFront(x interface{}) immut.Seq {
 	return xs.addTreeNode(x, s(x))
@@ -230,50 +298,49 @@ func (xs *Tree) AddBack(x interface{}) immut.Seq {
 }
 func (n Empty) AddBack(item interface{}) immut.Seq { return New(item) }
 
-// O(n*log(n))
+// O(m*log(n/m+1)) when adding m items to n
 func (xs *Tree) AddAll(that immut.Seq) immut.Seq {
-	//fmt.Printf("[%d].AddAll([%d])\n", xs.Len(), that.Len())
-	if that.IsEmpty() {
-		return xs
-	}
-	return xs.AddFront(that.Front()).AddAll(that.Rest())
-	//TODO, avoid xs creating very unbalanced trees
+	return union(xs, asTreeNode(that))
 }
 func (n Empty) AddAll(other immut.Seq) immut.Seq { return other }
 
+// O(n), visiting items in order
 func (xs *Tree) Forall(f func(interface{}) bool) bool {
-	return f(xs.value) && xs.left.Forall(f) && xs.right.Forall(f)
+	return xs.left.Forall(f) && f(xs.value) && xs.right.Forall(f)
 }
 func (Empty) Forall(f func(interface{}) bool) bool { return true }
 
+// O(n*log(n))
 func (xs *Tree) Map(f func(interface{}) interface{}) immut.Seq {
-	return New(f(xs.value)).AddAll(xs.left.Map(f)).AddAll(xs.right.Map(f))
+	ys := make([]interface{}, 0, xs.size)
+	xs.Do(func(x interface{}) { ys = append(ys, f(x)) })
+	return New(ys...)
 }
 func (n Empty) Map(f func(interface{}) interface{}) immut.Seq { return n }
 
+// O(n), sharing the subtrees where nothing is filtered out
 func (xs *Tree) Filter(f func(interface{}) bool) immut.Seq {
-	if xs.Forall(f) {
+	left := xs.left.Filter(f)
+	keep := f(xs.value)
+	right := xs.right.Filter(f)
+	switch {
+	case !keep:
+		return merge(left, right)
+	case left == xs.left && right == xs.right:
 		return xs
 	}
-	if f(xs.value) {
-		// root is included
-		return &Tree{
-			xs.value,
-			xs.valueS,
-			xs.left.Filter(f),
-			xs.right.Filter(f)}
-	}
-	// exclude root
+	return join(left, xs.value, xs.valueS, right)
+}
+func (n Empty) Filter(f func(interface{}) bool) immut.Seq { return n }
+
+// Split into the left subtree and the rest of the tree, sharing the
+// subtrees. O(log n) to rebalance the rest
+func (xs *Tree) Split() (immut.Seq, immut.Seq) {
 	if xs.left.IsEmpty() {
-		return xs.right.Filter(f)
+		return tree(xs.value, xs.valueS, Empty{}, Empty{}), xs.right
 	}
-	if xs.right.IsEmpty() {
-		return xs.left.Filter(f)
-	}
-	//tricky case: root is filtered out but left and right are not empty
-	return xs.left.Filter(f).AddAll(xs.right.Filter(f))
+	return xs.left, join(Empty{}, xs.value, xs.valueS, xs.right)
 }
-func (n Empty) Filter(f func(interface{}) bool) immut.Seq { return n }
 
 func (xs *Tree) String() string {
 	var buf bytes.Buffer
@@ -284,8 +351,9 @@ func (xs *Tree) String() string {
 }
 func (Empty) String() string { return "{}" }
 
+// O(log n)
 func (xs *Tree) Remove(match interface{}) immut.Seq {
-	return xs.Filter(func(x interface{}) bool { return x != match })
+	return remove(xs, match, s(match))
 }
 func (n Empty) Remove(x interface{}) immut.Seq { return n }
 
@@ -0,0 +1,168 @@
+package immut
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"runtime"
+)
+
+// A Splitter is a Seq that can divide itself into two non-empty parts,
+// the first holding the items before those in the second, so that they
+// can be processed in parallel.  Split is only called on a Seq with at
+// least two items, and should share structure rather than copy items
+// where it can.
+type Splitter interface {
+	Split() (Seq, Seq)
+}
+
+// ParMap is like xs.Map(f), but calls f from several goroutines at once,
+// splitting xs into about four parts for each of GOMAXPROCS if xs is a
+// Splitter.  The results of the parts are joined with AddAll, so a
+// sequence keeps its order and a set is the union of the parts.  Stops
+// early with the context's error if ctx is done.
+func ParMap(ctx context.Context, xs Seq, f func(interface{}) interface{}) (Seq, error) {
+	result, err := fork(ctx, xs,
+		func(part Seq, check func()) interface{} {
+			return part.Map(func(x interface{}) interface{} {
+				check()
+				return f(x)
+			})
+		},
+		appendSeqs)
+	if err != nil {
+		return nil, err
+	}
+	return result.(Seq), nil
+}
+
+// ParFilter is like xs.Filter(f), but calls f from several goroutines at
+// once, in the same way as ParMap.
+func ParFilter(ctx context.Context, xs Seq, f func(interface{}) bool) (Seq, error) {
+	result, err := fork(ctx, xs,
+		func(part Seq, check func()) interface{} {
+			return part.Filter(func(x interface{}) bool {
+				check()
+				return f(x)
+			})
+		},
+		appendSeqs)
+	if err != nil {
+		return nil, err
+	}
+	return result.(Seq), nil
+}
+
+// ParReduce folds the items of xs in parallel, in the style of Clojure
+// reducers: xs is split into parts as for ParMap, the items of each part
+// are folded with reduce starting from zero, and then the results of
+// adjacent parts are folded together with combine.  So zero must be an
+// identity for combine, and combine must be associative.  Stops early
+// with the context's error if ctx is done.
+func ParReduce(ctx context.Context, xs Seq, zero interface{},
+	reduce func(acc, x interface{}) interface{},
+	combine func(a, b interface{}) interface{}) (interface{}, error) {
+	return fork(ctx, xs,
+		func(part Seq, check func()) interface{} {
+			acc := zero
+			part.Do(func(x interface{}) {
+				check()
+				acc = reduce(acc, x)
+			})
+			return acc
+		},
+		combine)
+}
+
+// Everything below here is private
+
+// Parts smaller than this are not split further
+const minPart = 64
+
+// How often a part checks whether the context is done
+const checkEvery = 256
+
+// Signals that the context was done part way through a part.
+type cancelled struct{}
+
+func appendSeqs(a, b interface{}) interface{} { return a.(Seq).AddAll(b.(Seq)) }
+
+// Split xs into up to 4*GOMAXPROCS parts, apply leaf to each part in its
+// own goroutine, and join the results with combine.
+func fork(ctx context.Context, xs Seq,
+	leaf func(part Seq, check func()) interface{},
+	combine func(a, b interface{}) interface{}) (interface{}, error) {
+	depth := 2
+	for n := 1; n < runtime.GOMAXPROCS(0); n *= 2 {
+		depth++
+	}
+	return forkDepth(ctx, xs, depth, leaf, combine)
+}
+
+func forkDepth(ctx context.Context, xs Seq, depth int,
+	leaf func(part Seq, check func()) interface{},
+	combine func(a, b interface{}) interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, ok := xs.(Splitter)
+	if !ok || depth == 0 || xs.Len() < 2*minPart {
+		return runLeaf(ctx, xs, leaf)
+	}
+	front, back := s.Split()
+	var (
+		frontResult interface{}
+		frontErr    error
+		frontPanic  interface{}
+		done        = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		defer func() { frontPanic = recover() }()
+		frontResult, frontErr = forkDepth(ctx, front, depth-1, leaf, combine)
+	}()
+	backResult, backErr := forkDepth(ctx, back, depth-1, leaf, combine)
+	<-done
+	if frontPanic != nil {
+		panic(frontPanic) // re-raise on the calling goroutine
+	}
+	if frontErr != nil {
+		return nil, frontErr
+	}
+	if backErr != nil {
+		return nil, backErr
+	}
+	return combine(frontResult, backResult), nil
+}
+
+func runLeaf(ctx context.Context, xs Seq,
+	leaf func(part Seq, check func()) interface{}) (result interface{}, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if _, ok := e.(cancelled); !ok {
+				panic(e)
+			}
+			err = ctx.Err()
+		}
+	}()
+	n := 0
+	check := func() {
+		n++
+		if n%checkEvery == 0 && ctx.Err() != nil {
+			panic(cancelled{})
+		}
+	}
+	return leaf(xs, check), nil
+}
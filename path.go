@@ -0,0 +1,184 @@
+package immut
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "fmt"
+
+// A PathError reports a path into nested collections that could not be
+// followed.
+type PathError struct {
+	Path  []interface{}
+	Depth int // index in Path of the element that could not be followed
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("immut: no %v in path %v", e.Path[e.Depth], e.Path)
+}
+
+// GetIn returns the item found by following the path from root, where
+// root is a Seq or Map holding further Seqs and Maps.  Each element of
+// the path is an int index into a Seq or a key into a Map.  Returns a
+// *PathError if the path cannot be followed.  The items of a Set can be
+// read by index, in its own order, but not edited that way.
+func GetIn(root interface{}, path ...interface{}) (interface{}, error) {
+	x := root
+	for i, key := range path {
+		var ok bool
+		if x, ok = child(x, key); !ok {
+			return nil, &PathError{path, i}
+		}
+	}
+	return x, nil
+}
+
+// AssocIn returns a new version of root with value at the end of the
+// path, copying only the Seqs and Maps along the path.  The last element
+// of the path may be a new key of a Map, or the length of a Seq to add
+// the value at the end.  Returns a *PathError if the path cannot be
+// followed or goes by index into a Set.
+func AssocIn(root interface{}, path []interface{}, value interface{}) (interface{}, error) {
+	return editIn(root, path, 0,
+		func(interface{}, bool) (interface{}, bool, error) {
+			return value, false, nil
+		})
+}
+
+// UpdateIn returns a new version of root with the item x at the end of
+// the path replaced by f(x), copying only the Seqs and Maps along the
+// path.  Returns a *PathError if there is no such item or the path goes
+// by index into a Set.
+func UpdateIn(root interface{}, path []interface{}, f func(interface{}) interface{}) (interface{}, error) {
+	return editIn(root, path, 0,
+		func(x interface{}, found bool) (interface{}, bool, error) {
+			if !found {
+				return nil, false, &PathError{path, len(path) - 1}
+			}
+			return f(x), false, nil
+		})
+}
+
+// DissocIn returns a new version of root without the item at the end of
+// the path, copying only the Seqs and Maps along the path.  Returns a
+// *PathError if there is no such item or the path goes by index into a
+// Set.
+func DissocIn(root interface{}, path []interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("immut: cannot remove the root")
+	}
+	return editIn(root, path, 0,
+		func(x interface{}, found bool) (interface{}, bool, error) {
+			if !found {
+				return nil, false, &PathError{path, len(path) - 1}
+			}
+			return nil, true, nil
+		})
+}
+
+// Everything below here is private
+
+// Return the new version of node with the item at the end of
+// path[depth:] replaced by the result of edit, which is given the old
+// item and whether there was one, and returns the new item or whether to
+// remove it instead.
+func editIn(node interface{}, path []interface{}, depth int,
+	edit func(x interface{}, found bool) (interface{}, bool, error)) (interface{}, error) {
+	if len(path) == 0 {
+		x, _, err := edit(node, true)
+		return x, err
+	}
+	key := path[depth]
+	x, found := child(node, key)
+	var remove bool
+	var err error
+	if depth < len(path)-1 {
+		if !found {
+			return nil, &PathError{path, depth}
+		}
+		x, err = editIn(x, path, depth+1, edit)
+	} else {
+		x, remove, err = edit(x, found)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if remove {
+		node, ok = without(node, key)
+	} else {
+		node, ok = with(node, key, x)
+	}
+	if !ok {
+		return nil, &PathError{path, depth}
+	}
+	return node, nil
+}
+
+func child(node, key interface{}) (interface{}, bool) {
+	switch node := node.(type) {
+	case Map:
+		return node.Get(key)
+	case Seq:
+		if i, ok := key.(int); ok {
+			return node.Get(i)
+		}
+	}
+	return nil, false
+}
+
+// Return node with x at key, which may be one past the end of a Seq.
+// The items of a Set cannot be put back where they were, so it fails
+// for those.
+func with(node, key, x interface{}) (interface{}, bool) {
+	switch node := node.(type) {
+	case Map:
+		return node.Assoc(key, x), true
+	case Set:
+		return nil, false
+	case Seq:
+		i, ok := key.(int)
+		n := node.Len()
+		switch {
+		case !ok || i < 0 || i > n:
+			return nil, false
+		case i == n:
+			return node.AddBack(x), true
+		}
+		olds := node.Items()
+		news := make([]interface{}, n)
+		copy(news, olds)
+		news[i] = x
+		return rebuild(node, olds, news), true
+	}
+	return nil, false
+}
+
+func without(node, key interface{}) (interface{}, bool) {
+	switch node := node.(type) {
+	case Map:
+		return node.Dissoc(key), true
+	case Set:
+		return nil, false
+	case Seq:
+		i, ok := key.(int)
+		if !ok || i < 0 || i >= node.Len() {
+			return nil, false
+		}
+		olds := node.Items()
+		news := make([]interface{}, 0, len(olds)-1)
+		news = append(append(news, olds[:i]...), olds[i+1:]...)
+		return rebuild(node, olds, news), true
+	}
+	return nil, false
+}
@@ -0,0 +1,50 @@
+package rope_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/rope"
+	"io"
+	"os"
+	"strings"
+)
+
+func Example() {
+	doc := rope.New("Hello world\nsecond line\n")
+	var undo []*rope.Rope
+
+	undo = append(undo, doc)
+	doc = doc.Insert(5, ",")
+	undo = append(undo, doc)
+	doc = doc.Delete(7, 12).Insert(7, "rope")
+
+	fmt.Printf("%q\n", doc)
+	fmt.Printf("%q\n", undo[len(undo)-1])
+	fmt.Printf("%q\n", undo[0])
+	// Output:
+	// "Hello, rope\nsecond line\n"
+	// "Hello, world\nsecond line\n"
+	// "Hello world\nsecond line\n"
+}
+
+func ExampleRope_Position() {
+	doc := rope.New("naïve\ncafé au lait\n")
+	line, col := doc.Position(9)
+	fmt.Println(line, col, string(doc.Index(9)))
+	fmt.Println(doc.Lines(), doc.LineStart(1))
+	fmt.Println(doc.Slice(doc.LineStart(1), doc.LineStart(2)-1))
+	// Output:
+	// 1 3 é
+	// 3 6
+	// café au lait
+}
+
+func ExampleRope_Reader() {
+	big := rope.New(strings.Repeat("x", 2000)).Concat(rope.New("\nend\n"))
+	n, _ := io.Copy(io.Discard, big.Reader())
+	fmt.Println(n, big.Len())
+	io.Copy(os.Stdout, big.Slice(1995, big.Len()).Reader())
+	// Output:
+	// 2005 2005
+	// xxxxx
+	// end
+}
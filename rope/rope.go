@@ -0,0 +1,385 @@
+// The rope package provides persistent text as balanced trees of string
+// chunks (Boehm, Atkinson and Plass 1995).  Positions count runes.
+// Inserting or deleting makes a new rope in O(log n), sharing every
+// chunk the edit does not touch, so keeping old versions for undo costs
+// little.
+package rope
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Create a new rope of the text. O(n)
+func New(s string) *Rope { return &Rope{fromString(s)} }
+
+// A Rope is an immutable sequence of runes, which may be empty.
+type Rope struct {
+	root *node // nil if empty
+}
+
+// Len is the number of runes. O(1)
+func (r *Rope) Len() int { return runes(r.root) }
+
+// Index returns the rune at the position, panicking with an
+// *immut.IndexError if it is out of range. O(log n)
+func (r *Rope) Index(pos int) rune {
+	if pos < 0 || pos >= r.Len() {
+		panic(&immut.IndexError{Index: pos, Len: r.Len()})
+	}
+	n := r.root
+	for !n.isLeaf() {
+		if pos < n.left.runes {
+			n = n.left
+		} else {
+			pos -= n.left.runes
+			n = n.right
+		}
+	}
+	for _, c := range n.text {
+		if pos == 0 {
+			return c
+		}
+		pos--
+	}
+	panic("cannot happen")
+}
+
+// Insert returns the rope with s inserted before the rune at pos, or at
+// the end if pos is Len. O(log n + len(s))
+func (r *Rope) Insert(pos int, s string) *Rope {
+	r.check(pos)
+	if s == "" {
+		return r
+	}
+	left, right := split(r.root, pos)
+	return &Rope{join(join(left, fromString(s)), right)}
+}
+
+// Delete returns the rope without the runes from one position up to
+// but not including another. O(log n)
+func (r *Rope) Delete(from, to int) *Rope {
+	r.checkRange(from, to)
+	if from == to {
+		return r
+	}
+	left, rest := split(r.root, from)
+	_, right := split(rest, to-from)
+	return &Rope{join(left, right)}
+}
+
+// Slice returns the runes from one position up to but not including
+// another, sharing the chunks. O(log n)
+func (r *Rope) Slice(from, to int) *Rope {
+	r.checkRange(from, to)
+	_, rest := split(r.root, from)
+	middle, _ := split(rest, to-from)
+	return &Rope{middle}
+}
+
+// Concat returns this rope followed by the other. O(log n)
+func (r *Rope) Concat(other *Rope) *Rope { return &Rope{join(r.root, other.root)} }
+
+// Lines is the number of lines, which is one more than the number of
+// newlines. O(1)
+func (r *Rope) Lines() int {
+	if r.root == nil {
+		return 1
+	}
+	return r.root.lines + 1
+}
+
+// LineStart returns the position of the first rune of the line, where
+// the first line is 0, panicking with an *immut.IndexError if there is
+// no such line. O(log n)
+func (r *Rope) LineStart(line int) int {
+	if line < 0 || line >= r.Lines() {
+		panic(&immut.IndexError{Index: line, Len: r.Lines()})
+	}
+	if line == 0 {
+		return 0
+	}
+	// find the position after the line'th newline
+	pos, n := 0, r.root
+	for !n.isLeaf() {
+		if line <= n.left.lines {
+			n = n.left
+		} else {
+			line -= n.left.lines
+			pos += n.left.runes
+			n = n.right
+		}
+	}
+	for _, c := range n.text {
+		pos++
+		if c == '\n' {
+			line--
+			if line == 0 {
+				break
+			}
+		}
+	}
+	return pos
+}
+
+// Position returns the line and the column within the line, both
+// counting from 0, of the rune at pos, where pos may also be Len.
+// O(log n)
+func (r *Rope) Position(pos int) (line, col int) {
+	r.check(pos)
+	rest, n := pos, r.root
+	for n != nil && !n.isLeaf() {
+		if rest < n.left.runes {
+			n = n.left
+		} else {
+			line += n.left.lines
+			rest -= n.left.runes
+			n = n.right
+		}
+	}
+	if n != nil {
+		for _, c := range n.text {
+			if rest == 0 {
+				break
+			}
+			if c == '\n' {
+				line++
+			}
+			rest--
+		}
+	}
+	return line, pos - r.LineStart(line)
+}
+
+// Reader returns a reader of the text as UTF-8. O(1)
+func (r *Rope) Reader() io.Reader {
+	rd := &reader{}
+	rd.push(r.root)
+	return rd
+}
+
+// String returns the text. O(n)
+func (r *Rope) String() string {
+	var b strings.Builder
+	b.Grow(bytes(r.root))
+	forLeaves(r.root, func(s string) { b.WriteString(s) })
+	return b.String()
+}
+
+// Everything below here is private
+
+// Leaves hold at most this many bytes, except where a rune is longer
+const maxChunk = 512
+
+// A node is a leaf holding a chunk of text, or a branch with two
+// children whose heights differ by at most one
+type node struct {
+	left, right *node  // nil for a leaf
+	text        string // empty for a branch
+	height      int    // 0 for a leaf
+	runes       int
+	bytes       int
+	lines       int // number of newlines
+}
+
+func (n *node) isLeaf() bool { return n.left == nil }
+
+func leaf(s string) *node {
+	return &node{nil, nil, s, 0, utf8.RuneCountInString(s), len(s), strings.Count(s, "\n")}
+}
+
+func branch(left, right *node) *node {
+	return &node{left, right, "", 1 + max(left.height, right.height),
+		left.runes + right.runes, left.bytes + right.bytes, left.lines + right.lines}
+}
+
+func runes(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.runes
+}
+
+func bytes(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.bytes
+}
+
+func height(n *node) int {
+	if n == nil {
+		return -1
+	}
+	return n.height
+}
+
+func (r *Rope) check(pos int) {
+	if pos < 0 || pos > r.Len() {
+		panic(&immut.IndexError{Index: pos, Len: r.Len()})
+	}
+}
+
+func (r *Rope) checkRange(from, to int) {
+	r.check(from)
+	r.check(to)
+	if from > to {
+		panic(&immut.IndexError{Index: from, Len: to})
+	}
+}
+
+// Build a balanced tree of chunks of s. O(len(s))
+func fromString(s string) *node {
+	var leaves []*node
+	for len(s) > 0 {
+		n := min(len(s), maxChunk)
+		for n < len(s) && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		if n == 0 {
+			// a chunk smaller than one rune, so take the whole rune
+			_, n = utf8.DecodeRuneInString(s)
+		}
+		leaves = append(leaves, leaf(s[:n]))
+		s = s[n:]
+	}
+	return build(leaves)
+}
+
+func build(leaves []*node) *node {
+	switch len(leaves) {
+	case 0:
+		return nil
+	case 1:
+		return leaves[0]
+	}
+	mid := len(leaves) / 2
+	return branch(build(leaves[:mid]), build(leaves[mid:]))
+}
+
+// Return a balanced tree of left followed by right, merging small
+// chunks where they meet. O(|height(left)-height(right)|)
+func join(left, right *node) *node {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.isLeaf() && right.isLeaf() && left.bytes+right.bytes <= maxChunk:
+		return leaf(left.text + right.text)
+	case height(left) > height(right)+1:
+		return rebalance(left.left, join(left.right, right))
+	case height(right) > height(left)+1:
+		return rebalance(join(left, right.left), right.right)
+	case right.isLeaf() && !left.isLeaf() && left.right.isLeaf() &&
+		left.right.bytes+right.bytes <= maxChunk:
+		return join(left.left, leaf(left.right.text+right.text))
+	case left.isLeaf() && !right.isLeaf() && right.left.isLeaf() &&
+		left.bytes+right.left.bytes <= maxChunk:
+		return join(leaf(left.text+right.left.text), right.right)
+	}
+	return branch(left, right)
+}
+
+// Return a branch of a and b, rotating if their heights differ by two,
+// which is as much as they can after a join unless chunks were merged
+func rebalance(a, b *node) *node {
+	switch d := height(a) - height(b); {
+	case d > 2 || d < -2:
+		return join(a, b)
+	case d == 2:
+		if height(a.left) >= height(a.right) {
+			return branch(a.left, branch(a.right, b))
+		}
+		return branch(branch(a.left, a.right.left), branch(a.right.right, b))
+	case d == -2:
+		if height(b.right) >= height(b.left) {
+			return branch(branch(a, b.left), b.right)
+		}
+		return branch(branch(a, b.left.left), branch(b.left.right, b.right))
+	}
+	return branch(a, b)
+}
+
+// Split into the runes before pos and those from pos on. O(log n)
+func split(n *node, pos int) (*node, *node) {
+	switch {
+	case n == nil || pos <= 0:
+		return nil, n
+	case pos >= n.runes:
+		return n, nil
+	case n.isLeaf():
+		i := 0
+		for j := range n.text {
+			if pos == 0 {
+				i = j
+				break
+			}
+			pos--
+		}
+		return leaf(n.text[:i]), leaf(n.text[i:])
+	case pos <= n.left.runes:
+		left, right := split(n.left, pos)
+		return left, join(right, n.right)
+	}
+	left, right := split(n.right, pos-n.left.runes)
+	return join(n.left, left), right
+}
+
+func forLeaves(n *node, f func(string)) {
+	switch {
+	case n == nil:
+	case n.isLeaf():
+		f(n.text)
+	default:
+		forLeaves(n.left, f)
+		forLeaves(n.right, f)
+	}
+}
+
+// Reads the leaves in order, keeping the right branches still to read
+type reader struct {
+	stack []*node
+	text  string // the unread part of the current leaf
+}
+
+// Push n and the left branches below it, down to the leftmost leaf
+func (rd *reader) push(n *node) {
+	for n != nil && !n.isLeaf() {
+		rd.stack = append(rd.stack, n.right)
+		n = n.left
+	}
+	if n != nil {
+		rd.text = n.text
+	}
+}
+
+func (rd *reader) Read(p []byte) (int, error) {
+	for rd.text == "" {
+		if len(rd.stack) == 0 {
+			return 0, io.EOF
+		}
+		n := rd.stack[len(rd.stack)-1]
+		rd.stack = rd.stack[:len(rd.stack)-1]
+		rd.push(n)
+	}
+	k := copy(p, rd.text)
+	rd.text = rd.text[k:]
+	return k, nil
+}
@@ -0,0 +1,44 @@
+package spatial_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/spatial"
+)
+
+func Example() {
+	cafes := spatial.New(
+		spatial.Item{Box: spatial.Point{X: 1, Y: 1}.Box(), Value: "Blue Door"},
+		spatial.Item{Box: spatial.Point{X: 4, Y: 2}.Box(), Value: "Grind"},
+		spatial.Item{Box: spatial.Point{X: 9, Y: 8}.Box(), Value: "Late Shift"})
+	park := spatial.Rect(3, 0, 6, 5)
+	edited := cafes.Insert(spatial.Point{X: 5, Y: 4}.Box(), "Kiosk")
+
+	fmt.Println(cafes.Within(park))
+	fmt.Println(edited.Within(park).Len(), cafes.Within(park).Len())
+	fmt.Println(edited.Nearest(spatial.Point{X: 8, Y: 7}, 2))
+	// Output:
+	// [(4,2):Grind]
+	// 2 1
+	// [(9,8):Late Shift,(5,4):Kiosk]
+}
+
+func ExampleTree_Intersecting() {
+	lots := spatial.New().
+		Insert(spatial.Rect(0, 0, 10, 10), "A").
+		Insert(spatial.Rect(10, 0, 20, 10), "B").
+		Insert(spatial.Rect(0, 10, 10, 20), "C")
+	viewport := spatial.Rect(8, 8, 9, 12)
+	fmt.Println(lots.Intersecting(viewport).Len(), lots.Within(viewport).Len())
+	fmt.Println(lots.Bounds())
+	// Output:
+	// 2 0
+	// [(0,0),(20,20)]
+}
+
+func ExampleTree_Remove() {
+	pin := spatial.Item{Box: spatial.Point{X: 2, Y: 3}.Box(), Value: "pin"}
+	before := spatial.New(pin)
+	after := before.Remove(pin)
+	fmt.Println(before, after, after.Len())
+	// Output: {(2,3):pin} {} 0
+}
@@ -0,0 +1,112 @@
+package spatial
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"math"
+)
+
+// A Point in the plane
+type Point struct {
+	X, Y float64
+}
+
+// Box returns the box containing just the point
+func (p Point) Box() Box { return Box{p, p} }
+
+func (p Point) String() string { return fmt.Sprintf("(%g,%g)", p.X, p.Y) }
+
+// A Box is the closed rectangle from Min to Max, where Min is not
+// greater than Max on either axis.  A box with Min equal to Max is a
+// point.
+type Box struct {
+	Min, Max Point
+}
+
+// Rect returns the box with the two corners, in either order
+func Rect(x0, y0, x1, y1 float64) Box {
+	return Box{Point{math.Min(x0, x1), math.Min(y0, y1)}, Point{math.Max(x0, x1), math.Max(y0, y1)}}
+}
+
+// Contains is whether c is entirely inside b
+func (b Box) Contains(c Box) bool {
+	return b.Min.X <= c.Min.X && c.Max.X <= b.Max.X && b.Min.Y <= c.Min.Y && c.Max.Y <= b.Max.Y
+}
+
+// Intersects is whether b and c have any point in common
+func (b Box) Intersects(c Box) bool {
+	return b.Min.X <= c.Max.X && c.Min.X <= b.Max.X && b.Min.Y <= c.Max.Y && c.Min.Y <= b.Max.Y
+}
+
+// Distance is how far p is from the nearest point of b, which is zero
+// if p is inside b
+func (b Box) Distance(p Point) float64 {
+	dx := math.Max(0, math.Max(b.Min.X-p.X, p.X-b.Max.X))
+	dy := math.Max(0, math.Max(b.Min.Y-p.Y, p.Y-b.Max.Y))
+	return math.Hypot(dx, dy)
+}
+
+func (b Box) String() string {
+	if b.Min == b.Max {
+		return b.Min.String()
+	}
+	return fmt.Sprintf("[%v,%v]", b.Min, b.Max)
+}
+
+// An Item is a box with a payload Value
+type Item struct {
+	Box   Box
+	Value interface{}
+}
+
+func (it Item) String() string { return fmt.Sprintf("%v:%v", it.Box, it.Value) }
+
+// Everything below here is private
+
+func (b Box) valid() bool {
+	// written so that NaN is not valid
+	return b.Min.X <= b.Max.X && b.Min.Y <= b.Max.Y
+}
+
+// The smallest box containing both
+func (b Box) union(c Box) Box {
+	return Box{
+		Point{math.Min(b.Min.X, c.Min.X), math.Min(b.Min.Y, c.Min.Y)},
+		Point{math.Max(b.Max.X, c.Max.X), math.Max(b.Max.Y, c.Max.Y)}}
+}
+
+func (b Box) area() float64 { return (b.Max.X - b.Min.X) * (b.Max.Y - b.Min.Y) }
+
+// Half the perimeter
+func (b Box) margin() float64 { return b.Max.X - b.Min.X + b.Max.Y - b.Min.Y }
+
+// The area that b and c have in common
+func (b Box) overlap(c Box) float64 {
+	dx := math.Min(b.Max.X, c.Max.X) - math.Max(b.Min.X, c.Min.X)
+	dy := math.Min(b.Max.Y, c.Max.Y) - math.Max(b.Min.Y, c.Min.Y)
+	if dx <= 0 || dy <= 0 {
+		return 0
+	}
+	return dx * dy
+}
+
+// Twice the center on an axis, which orders boxes the same as the center
+func (b Box) center(axis int) float64 {
+	if axis == 0 {
+		return b.Min.X + b.Max.X
+	}
+	return b.Min.Y + b.Max.Y
+}
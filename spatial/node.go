@@ -0,0 +1,381 @@
+package spatial
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"container/heap"
+	"github.com/eobrain/immut"
+	"math"
+	"sort"
+)
+
+// Nodes have at most maxEntries children or items and are split in two
+// with at least minEntries each when they get more.  Removing never
+// merges nodes, it only drops the ones that become empty.
+const (
+	maxEntries = 9
+	minEntries = 4
+)
+
+// All the leaves are at the same depth.  A leaf has items and no kids,
+// and every other node has kids and no items.
+type node struct {
+	box   Box // containing everything below
+	size  int // number of items below
+	kids  []*node
+	items []Item
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *node) leaf() bool { return n.kids == nil }
+
+func newLeaf(items []Item) *node {
+	box := items[0].Box
+	for _, it := range items[1:] {
+		box = box.union(it.Box)
+	}
+	return &node{box, len(items), nil, items}
+}
+
+func newBranch(kids []*node) *node {
+	box, n := kids[0].box, 0
+	for _, kid := range kids {
+		box = box.union(kid.box)
+		n += kid.size
+	}
+	return &node{box, n, kids, nil}
+}
+
+// Pack the items into a tree with the Sort-Tile-Recursive algorithm,
+// which groups nearby items into full leaves. O(n*log(n))
+func pack(items []Item) *node {
+	if len(items) == 0 {
+		return nil
+	}
+	boxes := make([]Box, len(items))
+	for i, it := range items {
+		boxes[i] = it.Box
+	}
+	var level []*node
+	for _, group := range tile(boxes) {
+		leafItems := make([]Item, len(group))
+		for i, j := range group {
+			leafItems[i] = items[j]
+		}
+		level = append(level, newLeaf(leafItems))
+	}
+	for len(level) > 1 {
+		boxes = boxes[:0]
+		for _, n := range level {
+			boxes = append(boxes, n.box)
+		}
+		var parents []*node
+		for _, group := range tile(boxes) {
+			kids := make([]*node, len(group))
+			for i, j := range group {
+				kids[i] = level[j]
+			}
+			parents = append(parents, newBranch(kids))
+		}
+		level = parents
+	}
+	return level[0]
+}
+
+// Divide the boxes into vertical slices by their centers and each slice
+// into groups of up to maxEntries going up it, returning the indexes of
+// the boxes in each group
+func tile(boxes []Box) (groups [][]int) {
+	order := make([]int, len(boxes))
+	for i := range order {
+		order[i] = i
+	}
+	byCenter := func(part []int, axis int) {
+		sort.SliceStable(part, func(i, j int) bool {
+			return boxes[part[i]].center(axis) < boxes[part[j]].center(axis)
+		})
+	}
+	byCenter(order, 0)
+	leaves := (len(boxes) + maxEntries - 1) / maxEntries
+	slice := maxEntries * int(math.Ceil(math.Sqrt(float64(leaves))))
+	for start := 0; start < len(order); start += slice {
+		part := order[start:min(start+slice, len(order))]
+		byCenter(part, 1)
+		for i := 0; i < len(part); i += maxEntries {
+			groups = append(groups, part[i:min(i+maxEntries, len(part))])
+		}
+	}
+	return
+}
+
+// Return n with the item added, or two nodes to replace n if it had to
+// be split. O(log n)
+func insert(n *node, it Item) (*node, *node) {
+	if n.leaf() {
+		items := append(append(make([]Item, 0, len(n.items)+1), n.items...), it)
+		if len(items) <= maxEntries {
+			return newLeaf(items), nil
+		}
+		boxes := make([]Box, len(items))
+		for i, x := range items {
+			boxes[i] = x.Box
+		}
+		order, k := split(boxes)
+		sorted := make([]Item, len(order))
+		for i, j := range order {
+			sorted[i] = items[j]
+		}
+		return newLeaf(sorted[:k]), newLeaf(sorted[k:])
+	}
+	i := choose(n.kids, it.Box)
+	a, b := insert(n.kids[i], it)
+	kids := make([]*node, len(n.kids), len(n.kids)+1)
+	copy(kids, n.kids)
+	kids[i] = a
+	if b != nil {
+		kids = append(kids, b)
+	}
+	if len(kids) <= maxEntries {
+		return newBranch(kids), nil
+	}
+	boxes := make([]Box, len(kids))
+	for i, kid := range kids {
+		boxes[i] = kid.box
+	}
+	order, k := split(boxes)
+	sorted := make([]*node, len(order))
+	for i, j := range order {
+		sorted[i] = kids[j]
+	}
+	return newBranch(sorted[:k]), newBranch(sorted[k:])
+}
+
+// The index of the kid that grows least by adding the box, preferring
+// smaller kids when there is a tie
+func choose(kids []*node, b Box) int {
+	best := 0
+	bestGrowth, bestMargin, bestArea := math.Inf(1), math.Inf(1), math.Inf(1)
+	for i, kid := range kids {
+		u := kid.box.union(b)
+		growth := u.area() - kid.box.area()
+		margin := u.margin() - kid.box.margin()
+		area := kid.box.area()
+		if growth < bestGrowth ||
+			growth == bestGrowth && (margin < bestMargin ||
+				margin == bestMargin && area < bestArea) {
+			best, bestGrowth, bestMargin, bestArea = i, growth, margin, area
+		}
+	}
+	return best
+}
+
+// Return an order of the boxes and a place to cut it into two groups,
+// as in an R*-tree: sorted on the axis where the groups have the least
+// margin, cut where they overlap least
+func split(boxes []Box) ([]int, int) {
+	var order []int
+	bestMargin := math.Inf(1)
+	for axis := 0; axis < 2; axis++ {
+		sorted := make([]int, len(boxes))
+		for i := range sorted {
+			sorted[i] = i
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return boxes[sorted[i]].center(axis) < boxes[sorted[j]].center(axis)
+		})
+		margin := 0.0
+		for k := minEntries; k <= len(boxes)-minEntries; k++ {
+			a, b := cover(boxes, sorted[:k]), cover(boxes, sorted[k:])
+			margin += a.margin() + b.margin()
+		}
+		if margin < bestMargin {
+			order, bestMargin = sorted, margin
+		}
+	}
+	cut := minEntries
+	bestOverlap, bestArea := math.Inf(1), math.Inf(1)
+	for k := minEntries; k <= len(boxes)-minEntries; k++ {
+		a, b := cover(boxes, order[:k]), cover(boxes, order[k:])
+		overlap, area := a.overlap(b), a.area()+b.area()
+		if overlap < bestOverlap || overlap == bestOverlap && area < bestArea {
+			cut, bestOverlap, bestArea = k, overlap, area
+		}
+	}
+	return order, cut
+}
+
+// The smallest box containing the boxes at the indexes
+func cover(boxes []Box, indexes []int) Box {
+	box := boxes[indexes[0]]
+	for _, i := range indexes[1:] {
+		box = box.union(boxes[i])
+	}
+	return box
+}
+
+func same(a, b Item) bool { return a.Box == b.Box && immut.Identical(a.Value, b.Value) }
+
+// Return n without the first item equal to it, nil if that leaves it
+// empty, or n itself if the item is not there. O(log n) when the boxes
+// of the nodes do not overlap much
+func remove(n *node, it Item) *node {
+	if !n.box.Contains(it.Box) {
+		return n
+	}
+	if n.leaf() {
+		for i, x := range n.items {
+			if same(x, it) {
+				if len(n.items) == 1 {
+					return nil
+				}
+				items := append(append(make([]Item, 0, len(n.items)-1), n.items[:i]...), n.items[i+1:]...)
+				return newLeaf(items)
+			}
+		}
+		return n
+	}
+	for i, kid := range n.kids {
+		k := remove(kid, it)
+		if k == kid {
+			continue
+		}
+		kids := append(make([]*node, 0, len(n.kids)), n.kids[:i]...)
+		if k != nil {
+			kids = append(kids, k)
+		}
+		kids = append(kids, n.kids[i+1:]...)
+		if len(kids) == 0 {
+			return nil
+		}
+		return newBranch(kids)
+	}
+	return n
+}
+
+func (n *node) find(it Item) bool {
+	if n == nil || !n.box.Contains(it.Box) {
+		return false
+	}
+	for _, x := range n.items {
+		if same(x, it) {
+			return true
+		}
+	}
+	for _, kid := range n.kids {
+		if kid.find(it) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *node) forall(f func(Item) bool) bool {
+	if n == nil {
+		return true
+	}
+	for _, it := range n.items {
+		if !f(it) {
+			return false
+		}
+	}
+	for _, kid := range n.kids {
+		if !kid.forall(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// Collect the items inside b, or if touching is set the items that
+// intersect b
+func (n *node) search(b Box, touching bool, out *[]interface{}) {
+	if n == nil || !b.Intersects(n.box) {
+		return
+	}
+	if b.Contains(n.box) {
+		n.forall(func(it Item) bool {
+			*out = append(*out, it)
+			return true
+		})
+		return
+	}
+	for _, it := range n.items {
+		if b.Contains(it.Box) || touching && b.Intersects(it.Box) {
+			*out = append(*out, it)
+		}
+	}
+	for _, kid := range n.kids {
+		kid.search(b, touching, out)
+	}
+}
+
+// A queue of nodes and items ordered by distance, for finding the
+// nearest items by best-first search
+type queue []entry
+
+type entry struct {
+	distance float64
+	seq      int // for a stable order among equal distances
+	n        *node
+	it       Item
+}
+
+func (q queue) Len() int { return len(q) }
+func (q queue) Less(i, j int) bool {
+	if q[i].distance != q[j].distance {
+		return q[i].distance < q[j].distance
+	}
+	return q[i].seq < q[j].seq
+}
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(entry)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// Collect the k items nearest p, nearest first
+func (n *node) nearest(p Point, k int) []interface{} {
+	var out []interface{}
+	if n == nil || k <= 0 {
+		return out
+	}
+	q := &queue{{n.box.Distance(p), 0, n, Item{}}}
+	seq := 1
+	for q.Len() > 0 && len(out) < k {
+		e := heap.Pop(q).(entry)
+		if e.n == nil {
+			out = append(out, e.it)
+			continue
+		}
+		for _, it := range e.n.items {
+			heap.Push(q, entry{it.Box.Distance(p), seq, nil, it})
+			seq++
+		}
+		for _, kid := range e.n.kids {
+			heap.Push(q, entry{kid.box.Distance(p), seq, kid, Item{}})
+			seq++
+		}
+	}
+	return out
+}
@@ -0,0 +1,268 @@
+// The spatial package provides persistent R-trees, which index items
+// with boxes in the plane so that the ones in a region or nearest a
+// point can be found without looking at the others.  Versions share
+// all the nodes that are not on the path to a change.
+package spatial
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/vector"
+	"io"
+)
+
+// Create a new tree of the items, packed so that nearby items share
+// leaves. O(n*log(n))
+func New(items ...Item) *Tree {
+	for _, it := range items {
+		check(it)
+	}
+	return &Tree{pack(append([]Item(nil), items...))}
+}
+
+// A Seq of Item values, in an order that groups nearby items, which may
+// be empty
+type Tree struct {
+	root *node // nil if empty
+}
+
+// Insert returns the tree with an item of the box and value added.
+// O(log n)
+func (t *Tree) Insert(b Box, value interface{}) *Tree { return t.add(Item{b, value}) }
+
+// Within returns the items whose boxes are entirely inside b, as a
+// vector. O(log n + k) for k items when the boxes do not overlap much
+func (t *Tree) Within(b Box) immut.Seq {
+	var items []interface{}
+	t.root.search(b, false, &items)
+	return vector.New(items...)
+}
+
+// Intersecting returns the items whose boxes have any point in common
+// with b, as a vector. O(log n + k) for k items when the boxes do not
+// overlap much
+func (t *Tree) Intersecting(b Box) immut.Seq {
+	var items []interface{}
+	t.root.search(b, true, &items)
+	return vector.New(items...)
+}
+
+// Nearest returns the k items whose boxes are nearest the point,
+// nearest first, as a vector. O(k*log n) when the boxes do not overlap
+// much
+func (t *Tree) Nearest(p Point, k int) immut.Seq {
+	return vector.New(t.root.nearest(p, k)...)
+}
+
+// Bounds returns the smallest box containing all the items, or the zero
+// Box if there are none. O(1)
+func (t *Tree) Bounds() Box {
+	if t.root == nil {
+		return Box{}
+	}
+	return t.root.box
+}
+
+// Everything below here is private
+
+// Panic if x is not an Item with a valid box
+func check(x interface{}) Item {
+	it, ok := x.(Item)
+	if !ok {
+		panic(fmt.Sprintf("spatial: %T item %v is not an Item", x, x))
+	}
+	if !it.Box.valid() {
+		panic(fmt.Sprintf("spatial: %v has Min greater than Max", it.Box))
+	}
+	return it
+}
+
+// O(log n)
+func (t *Tree) add(it Item) *Tree {
+	check(it)
+	if t.root == nil {
+		return &Tree{newLeaf([]Item{it})}
+	}
+	a, b := insert(t.root, it)
+	if b != nil {
+		// the root split, so the tree grows a level
+		a = newBranch([]*node{a, b})
+	}
+	return &Tree{a}
+}
+
+// O(1)
+func (t *Tree) Len() int { return size(t.root) }
+
+// O(log n)
+func (t *Tree) Get(i int) (interface{}, bool) {
+	if i < 0 || i >= t.Len() {
+		return nil, false
+	}
+	n := t.root
+	for !n.leaf() {
+		for _, kid := range n.kids {
+			if i < kid.size {
+				n = kid
+				break
+			}
+			i -= kid.size
+		}
+	}
+	return n.items[i], true
+}
+
+// O(log n) when the boxes do not overlap much
+func (t *Tree) Contains(x interface{}) bool {
+	it, ok := x.(Item)
+	return ok && t.root.find(it)
+}
+
+// O(log n)
+func (t *Tree) Front() interface{} {
+	if t.root == nil {
+		panic("getting Front of empty seq")
+	}
+	x, _ := t.Get(0)
+	return x
+}
+
+// O(log n)
+func (t *Tree) Back() interface{} {
+	if t.root == nil {
+		panic("getting Back of empty seq")
+	}
+	x, _ := t.Get(t.Len() - 1)
+	return x
+}
+
+// O(log n)
+func (t *Tree) Rest() immut.Seq {
+	if t.root == nil {
+		panic("getting Rest of empty seq")
+	}
+	return t.Remove(t.Front())
+}
+
+// O(1)
+func (t *Tree) IsEmpty() bool { return t.root == nil }
+
+// O(n)
+func (t *Tree) Do(f func(interface{})) {
+	t.root.forall(func(it Item) bool {
+		f(it)
+		return true
+	})
+}
+
+// O(n)
+func (t *Tree) DoBackwards(f func(interface{})) {
+	items := t.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		f(items[i])
+	}
+}
+
+// O(n)
+func (t *Tree) Join(sep string, out io.Writer) {
+	s := ""
+	t.Do(func(x interface{}) {
+		fmt.Fprintf(out, "%s%v", s, x)
+		s = sep
+	})
+}
+
+// Cannot reverse a spatial index, so just return the tree itself
+func (t *Tree) Reverse() immut.Seq { return t }
+
+// Kept in an order that groups nearby items, so an immut.Set
+func (t *Tree) IsSet() {}
+
+// O(log n)
+func (t *Tree) AddFront(x interface{}) immut.Seq { return t.add(check(x)) }
+
+// O(log n)
+func (t *Tree) AddBack(x interface{}) immut.Seq { return t.add(check(x)) }
+
+// O(m*log(n+m))
+func (t *Tree) AddAll(that immut.Seq) immut.Seq {
+	result := t
+	that.Do(func(x interface{}) { result = result.add(check(x)) })
+	return result
+}
+
+// O(n)
+func (t *Tree) Forall(f func(interface{}) bool) bool {
+	return t.root.forall(func(it Item) bool { return f(it) })
+}
+
+// O(n*log(n)), panicking if f returns something that is not an Item
+func (t *Tree) Map(f func(interface{}) interface{}) immut.Seq {
+	items := make([]Item, 0, t.Len())
+	t.Do(func(x interface{}) { items = append(items, check(f(x))) })
+	return New(items...)
+}
+
+// O(n*log(n))
+func (t *Tree) Filter(f func(interface{}) bool) immut.Seq {
+	items := make([]Item, 0, t.Len())
+	t.root.forall(func(it Item) bool {
+		if f(it) {
+			items = append(items, it)
+		}
+		return true
+	})
+	if len(items) == t.Len() {
+		return t
+	}
+	return &Tree{pack(items)}
+}
+
+// Remove returns the tree without the first item equal to x, which has
+// the same box and an identical Value. O(log n) when the boxes do not
+// overlap much
+func (t *Tree) Remove(x interface{}) immut.Seq {
+	it, ok := x.(Item)
+	if !ok || t.root == nil {
+		return t
+	}
+	root := remove(t.root, it)
+	if root == t.root {
+		return t
+	}
+	for root != nil && len(root.kids) == 1 {
+		// the tree shrinks a level
+		root = root.kids[0]
+	}
+	return &Tree{root}
+}
+
+// O(n)
+func (t *Tree) Items() []interface{} {
+	ys := make([]interface{}, 0, t.Len())
+	t.Do(func(x interface{}) { ys = append(ys, x) })
+	return ys
+}
+
+func (t *Tree) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	t.Join(",", &buf)
+	buf.WriteString("}")
+	return buf.String()
+}
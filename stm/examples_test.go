@@ -0,0 +1,91 @@
+package stm_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/ordered"
+	"github.com/eobrain/immut/stm"
+	"sync"
+)
+
+func Example() {
+	todo := stm.NewRef(ordered.New("a", "b", "c", "d"))
+	done := stm.NewRef(ordered.New())
+
+	// Move every item from one set to the other, concurrently
+	var wg sync.WaitGroup
+	for _, item := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(item string) {
+			defer wg.Done()
+			stm.Transaction(func(tx *stm.Tx) error {
+				tx.Alter(todo, func(xs interface{}) interface{} {
+					return xs.(immut.Seq).Remove(item)
+				})
+				tx.Alter(done, func(xs interface{}) interface{} {
+					return xs.(immut.Seq).AddFront(item)
+				})
+				return nil
+			})
+		}(item)
+	}
+	wg.Wait()
+	fmt.Println(todo.Deref(), done.Deref())
+	// Output:
+	// {} {a,b,c,d}
+}
+
+func ExampleTx_Commute() {
+	counter := stm.NewRef(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stm.Transaction(func(tx *stm.Tx) error {
+				tx.Commute(counter, func(x interface{}) interface{} {
+					return x.(int) + 1
+				})
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	fmt.Println(counter.Deref())
+	// Output:
+	// 100
+}
+
+func ExampleTransaction_error() {
+	stock := stm.NewRef(ordered.New("apple"))
+	err := stm.Transaction(func(tx *stm.Tx) error {
+		tx.Set(stock, ordered.New())
+		return errors.New("changed my mind")
+	})
+	fmt.Println(err, stock.Deref())
+	// Output:
+	// changed my mind {apple}
+}
+
+func ExampleTx_Ensure() {
+	// Keep the total of two accounts at least zero
+	a, b := stm.NewRef(50), stm.NewRef(50)
+	withdraw := func(from, other *stm.Ref, amount int) error {
+		return stm.Transaction(func(tx *stm.Tx) error {
+			if tx.Deref(from).(int)+tx.Ensure(other).(int) < amount {
+				return errors.New("insufficient funds")
+			}
+			tx.Set(from, tx.Deref(from).(int)-amount)
+			return nil
+		})
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); withdraw(a, b, 80) }()
+	go func() { defer wg.Done(); withdraw(b, a, 80) }()
+	wg.Wait()
+	fmt.Println(a.Deref().(int)+b.Deref().(int) >= 0)
+	// Output:
+	// true
+}
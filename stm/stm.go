@@ -0,0 +1,242 @@
+// The stm package provides software transactional memory in the style of
+// Clojure refs: several Refs holding immutable values, such as immut.Seq
+// collections, can be changed together in one Transaction.  Reads within
+// a transaction see a consistent snapshot, and a transaction that
+// conflicts with another is retried automatically.
+package stm
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// A Ref is a mutable reference to an immutable value that can only be
+// changed within a Transaction.
+type Ref struct {
+	id uint64
+
+	mu      sync.RWMutex // guards history
+	history *version
+}
+
+// A Tx is a transaction in progress, passed to the function run by
+// Transaction.  It must not be used after that function returns.
+type Tx struct {
+	readPoint uint64
+	values    map[*Ref]interface{}
+	sets      map[*Ref]bool
+	ensures   map[*Ref]bool
+	commutes  map[*Ref][]func(interface{}) interface{}
+}
+
+// ErrRetryLimit is returned by a Transaction that conflicted with other
+// transactions too many times in a row.
+var ErrRetryLimit = errors.New("stm: transaction retry limit reached")
+
+// Create a new Ref holding x.
+func NewRef(x interface{}) *Ref {
+	return &Ref{
+		id:      ids.Add(1),
+		history: &version{x, 0, nil},
+	}
+}
+
+// Deref returns the latest committed value of the ref.  Within a
+// transaction use Tx.Deref instead.
+func (r *Ref) Deref() interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.history.value
+}
+
+// Transaction runs fn, then commits the changes it made to Refs through
+// tx, all together.  If another transaction committed a conflicting
+// change first fn is run again, so fn may be called more than once and
+// should have no side effects beyond those made through tx.  If fn
+// returns an error nothing is committed and the error is returned.
+func Transaction(fn func(tx *Tx) error) error {
+	for i := 0; i < retryLimit; i++ {
+		tx := &Tx{
+			readPoint: clock.Load(),
+			values:    make(map[*Ref]interface{}),
+			sets:      make(map[*Ref]bool),
+			ensures:   make(map[*Ref]bool),
+			commutes:  make(map[*Ref][]func(interface{}) interface{}),
+		}
+		conflicted, err := tx.run(fn)
+		if !conflicted {
+			if err != nil {
+				return err
+			}
+			if tx.commit() {
+				return nil
+			}
+		}
+		runtime.Gosched()
+	}
+	return ErrRetryLimit
+}
+
+// Deref returns the value of r as seen by this transaction: the value it
+// had when the transaction started, or the value this transaction gave
+// it.
+func (tx *Tx) Deref(r *Ref) interface{} {
+	if x, ok := tx.values[r]; ok {
+		return x
+	}
+	x := r.at(tx.readPoint)
+	tx.values[r] = x
+	return x
+}
+
+// Set gives r the value x.  The transaction is retried if another
+// transaction commits a change to r first.
+func (tx *Tx) Set(r *Ref, x interface{}) {
+	tx.Deref(r)
+	delete(tx.commutes, r)
+	tx.sets[r] = true
+	tx.values[r] = x
+}
+
+// Alter gives r the value f(x), where x is its value as seen by this
+// transaction, returning the new value.  The transaction is retried if
+// another transaction commits a change to r first.
+func (tx *Tx) Alter(r *Ref, f func(interface{}) interface{}) interface{} {
+	x := f(tx.Deref(r))
+	tx.Set(r, x)
+	return x
+}
+
+// Commute gives r the value f(x), where x is its value as seen by this
+// transaction, returning the new value.  Unlike Alter, a change to r by
+// another transaction does not cause a retry: instead f is applied again
+// to the latest value when committing.  Use it only when the order in
+// which such functions are applied does not matter, as with adding to a
+// set or incrementing a counter.
+func (tx *Tx) Commute(r *Ref, f func(interface{}) interface{}) interface{} {
+	x := f(tx.Deref(r))
+	tx.values[r] = x
+	if !tx.sets[r] {
+		tx.commutes[r] = append(tx.commutes[r], f)
+	}
+	return x
+}
+
+// Ensure protects r from changes by other transactions, so that this
+// transaction is retried if one commits a change to r first, even though
+// this transaction does not change it.  Returns the value of r.
+func (tx *Tx) Ensure(r *Ref) interface{} {
+	x := tx.Deref(r)
+	tx.ensures[r] = true
+	return x
+}
+
+// Everything below here is private
+
+const (
+	retryLimit = 10000
+	maxHistory = 10 // old values kept for transactions already running
+)
+
+var (
+	clock atomic.Uint64 // stamp of the latest commit
+	ids   atomic.Uint64
+)
+
+type version struct {
+	value interface{}
+	stamp uint64
+	prev  *version
+}
+
+// Signals that the transaction must be retried.
+type conflict struct{}
+
+// Return the value r had at the given point, aborting the transaction
+// if the history no longer goes back that far.
+func (r *Ref) at(point uint64) interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for v := r.history; v != nil; v = v.prev {
+		if v.stamp <= point {
+			return v.value
+		}
+	}
+	panic(conflict{})
+}
+
+func (tx *Tx) run(fn func(tx *Tx) error) (conflicted bool, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if _, ok := e.(conflict); !ok {
+				panic(e)
+			}
+			conflicted = true
+		}
+	}()
+	return false, fn(tx)
+}
+
+// Commit the changes, returning false if there was a conflict.
+func (tx *Tx) commit() bool {
+	// Lock in a consistent order to avoid deadlock.  The locks are held
+	// until the new values are in place, so that a transaction starting
+	// after the clock advances cannot see the old values.
+	var refs []*Ref
+	for r := range tx.values {
+		if tx.sets[r] || tx.ensures[r] || tx.commutes[r] != nil {
+			refs = append(refs, r)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].id < refs[j].id })
+	for _, r := range refs {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	}
+
+	for _, r := range refs {
+		if (tx.sets[r] || tx.ensures[r]) && r.history.stamp > tx.readPoint {
+			return false
+		}
+	}
+	for r, fs := range tx.commutes {
+		x := r.history.value
+		for _, f := range fs {
+			x = f(x)
+		}
+		tx.values[r] = x
+	}
+	stamp := clock.Add(1)
+	for _, r := range refs {
+		if tx.sets[r] || tx.commutes[r] != nil {
+			r.history = trim(&version{tx.values[r], stamp, r.history})
+		}
+	}
+	return true
+}
+
+func trim(v *version) *version {
+	w := v
+	for i := 1; i < maxHistory && w.prev != nil; i++ {
+		w = w.prev
+	}
+	w.prev = nil
+	return v
+}
@@ -0,0 +1,66 @@
+package immut
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "context"
+
+// DoContext calls f on each item of xs in turn, in the order Forall
+// visits them, stopping at the first error returned by f or when ctx is
+// done, and returning that error.
+func DoContext(ctx context.Context, xs Seq, f func(interface{}) error) (err error) {
+	xs.Forall(func(x interface{}) bool {
+		if err = ctx.Err(); err == nil {
+			err = f(x)
+		}
+		return err == nil
+	})
+	return
+}
+
+// Stream returns a channel on which a new goroutine sends the items of
+// xs in turn.  The channel is closed after the last item, or when ctx is
+// done.
+func Stream(ctx context.Context, xs Seq) <-chan interface{} {
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		DoContext(ctx, xs, func(x interface{}) error {
+			select {
+			case ch <- x:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return ch
+}
+
+// Collect returns the items received from ch until it is closed, or the
+// context's error if ctx is done first.
+func Collect(ctx context.Context, ch <-chan interface{}) ([]interface{}, error) {
+	var items []interface{}
+	for {
+		select {
+		case x, ok := <-ch:
+			if !ok {
+				return items, nil
+			}
+			items = append(items, x)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
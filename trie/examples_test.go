@@ -0,0 +1,50 @@
+package trie_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/trie"
+)
+
+func Example() {
+	paths := trie.New("/api/v1/users", "/api/v2/users", "/api/v2/orders",
+		"/static/app.js", "/api/v2/orders/recent")
+	fmt.Println(paths)
+	fmt.Println(paths.(*trie.Trie).WithPrefix("/api/v2/"))
+	fmt.Println(paths.(*trie.Trie).WithPrefix("/api/v3/").IsEmpty())
+	// Output:
+	// {/api/v1/users,/api/v2/orders,/api/v2/orders/recent,/api/v2/users,/static/app.js}
+	// {/api/v2/orders,/api/v2/orders/recent,/api/v2/users}
+	// true
+}
+
+func ExampleTrie_LongestPrefixOf() {
+	hosts := trie.New("com.example", "com.example.mail", "org")
+	fmt.Println(hosts.(*trie.Trie).LongestPrefixOf("com.example.mail.eu"))
+	fmt.Println(hosts.(*trie.Trie).LongestPrefixOf("com.example.www"))
+	fmt.Println(hosts.(*trie.Trie).LongestPrefixOf("net.example"))
+	// Output:
+	// com.example.mail true
+	// com.example true
+	//  false
+}
+
+func ExampleMap_LongestPrefixOf() {
+	routes := trie.NewMap(
+		"/", "index",
+		"/api/", "api",
+		"/api/v2/", "api v2")
+	newer := routes.Assoc("/api/v2/admin/", "admin")
+
+	for _, path := range []string{"/api/v2/users", "/api/v2/admin/x", "/about"} {
+		_, handler, _ := newer.(*trie.Map).LongestPrefixOf(path)
+		fmt.Println(path, "->", handler)
+	}
+	fmt.Println(routes.Len(), newer.Len())
+	fmt.Println(newer.(*trie.Map).WithPrefix("/api/v2/"))
+	// Output:
+	// /api/v2/users -> api v2
+	// /api/v2/admin/x -> admin
+	// /about -> index
+	// 3 4
+	// {/api/v2/:api v2,/api/v2/admin/:admin}
+}
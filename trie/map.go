@@ -0,0 +1,131 @@
+package trie
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+)
+
+// Create a new map from alternating keys and values, where the keys
+// must be strings. O(n*k) for n keys of length k
+func NewMap(keyValues ...interface{}) immut.Map {
+	if len(keyValues)%2 != 0 {
+		panic("odd number of arguments to NewMap")
+	}
+	m := &Map{}
+	for i := 0; i < len(keyValues); i += 2 {
+		m = m.assoc(keyValues[i], keyValues[i+1])
+	}
+	return m
+}
+
+// A Map from strings implemented as a radix tree, which may be empty
+type Map struct {
+	root *node // nil if empty
+}
+
+// WithPrefix returns the entries whose keys start with the prefix,
+// sharing them with this map. O(len(prefix))
+func (m *Map) WithPrefix(prefix string) immut.Map {
+	if m.root == nil {
+		return m
+	}
+	return &Map{m.root.withPrefix(prefix)}
+}
+
+// LongestPrefixOf returns the longest key in the map that is a prefix
+// of s, its value, and whether there is any such key. O(len(s))
+func (m *Map) LongestPrefixOf(s string) (string, interface{}, bool) {
+	key, n := m.root.longestPrefixOf(s)
+	if n == nil {
+		return "", nil, false
+	}
+	return key, n.value, true
+}
+
+// Everything below here is private
+
+// O(len(k))
+func (m *Map) assoc(k, v interface{}) *Map {
+	root, _ := m.root.insert(key(k), v)
+	if root == m.root {
+		return m
+	}
+	return &Map{root}
+}
+
+// O(1)
+func (m *Map) Len() int {
+	if m.root == nil {
+		return 0
+	}
+	return m.root.size
+}
+
+// O(len(k))
+func (m *Map) Get(k interface{}) (interface{}, bool) {
+	s, ok := k.(string)
+	if !ok {
+		return nil, false
+	}
+	n := m.root.lookup(s)
+	if n == nil || !n.terminal {
+		return nil, false
+	}
+	return n.value, true
+}
+
+// O(len(k))
+func (m *Map) Assoc(k, v interface{}) immut.Map { return m.assoc(k, v) }
+
+// O(len(k))
+func (m *Map) Dissoc(k interface{}) immut.Map {
+	s, ok := k.(string)
+	if !ok || m.root == nil {
+		return m
+	}
+	root := m.root.remove(s)
+	if root == m.root {
+		return m
+	}
+	return &Map{root}
+}
+
+// A trie sharing the nodes of the map. O(1)
+func (m *Map) Keys() immut.Seq { return &Trie{m.root} }
+
+// O(n), visiting keys in order
+func (m *Map) Do(f func(key, value interface{})) {
+	if m.root != nil {
+		m.root.forall("", func(k string, v interface{}) bool {
+			f(k, v)
+			return true
+		})
+	}
+}
+
+func (m *Map) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	sep := ""
+	m.Do(func(k, v interface{}) {
+		fmt.Fprintf(&buf, "%s%v:%v", sep, k, v)
+		sep = ","
+	})
+	buf.WriteString("}")
+	return buf.String()
+}
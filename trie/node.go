@@ -0,0 +1,300 @@
+package trie
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"strings"
+)
+
+// Everything below here is private
+
+// A node of a radix tree.  The key of a node is the concatenation of the
+// labels from the root down to it, except that the label of the root of
+// a Trie or Map is its whole key, so that a subtree can be shared as the
+// root of another Trie just by relabelling a copy of its root.
+type node struct {
+	label    string
+	terminal bool        // whether the key of this node is in the set
+	value    interface{} // associated with the key if terminal
+	children []*node     // non-empty labels, in order of first byte
+	size     int         // number of terminal nodes here and below
+}
+
+func newNode(label string, terminal bool, value interface{}, children []*node) *node {
+	size := 0
+	if terminal {
+		size = 1
+	}
+	for _, c := range children {
+		size += c.size
+	}
+	return &node{label, terminal, value, children, size}
+}
+
+// Return a copy of n with a different label
+func (n *node) relabel(label string) *node {
+	return &node{label, n.terminal, n.value, n.children, n.size}
+}
+
+// Index of the child whose label starts with b, or where it would go
+func (n *node) find(b byte) (int, bool) {
+	lo, hi := 0, len(n.children)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if n.children[mid].label[0] < b {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < len(n.children) && n.children[lo].label[0] == b
+}
+
+// Return the node for key, where key is relative to the start of n's
+// label, or nil. O(len(key))
+func (n *node) lookup(key string) *node {
+	for n != nil {
+		if !strings.HasPrefix(key, n.label) {
+			return nil
+		}
+		key = key[len(n.label):]
+		if key == "" {
+			return n
+		}
+		i, ok := n.find(key[0])
+		if !ok {
+			return nil
+		}
+		n = n.children[i]
+	}
+	return nil
+}
+
+// Return n with key associated with v, and whether key is new.
+// O(len(key))
+func (n *node) insert(key string, v interface{}) (*node, bool) {
+	if n == nil {
+		return newNode(key, true, v, nil), true
+	}
+	common := commonPrefix(n.label, key)
+	if common < len(n.label) {
+		// split this node's label
+		tail := n.relabel(n.label[common:])
+		if common == len(key) {
+			return newNode(key, true, v, []*node{tail}), true
+		}
+		leaf := newNode(key[common:], true, v, nil)
+		children := []*node{tail, leaf}
+		if leaf.label[0] < tail.label[0] {
+			children[0], children[1] = leaf, tail
+		}
+		return newNode(n.label[:common], false, nil, children), true
+	}
+	rest := key[common:]
+	if rest == "" {
+		if n.terminal && immut.Identical(n.value, v) {
+			return n, false
+		}
+		return newNode(n.label, true, v, n.children), !n.terminal
+	}
+	i, ok := n.find(rest[0])
+	if !ok {
+		children := make([]*node, len(n.children)+1)
+		copy(children, n.children[:i])
+		children[i] = newNode(rest, true, v, nil)
+		copy(children[i+1:], n.children[i:])
+		return newNode(n.label, n.terminal, n.value, children), true
+	}
+	child, added := n.children[i].insert(rest, v)
+	if child == n.children[i] {
+		return n, false
+	}
+	return n.withChild(i, child), added
+}
+
+// Return n without key, n itself if key is not there, or nil if
+// nothing is left. O(len(key))
+func (n *node) remove(key string) *node {
+	if !strings.HasPrefix(key, n.label) {
+		return n
+	}
+	rest := key[len(n.label):]
+	if rest == "" {
+		if !n.terminal {
+			return n
+		}
+		return newNode(n.label, false, nil, n.children).compact()
+	}
+	i, ok := n.find(rest[0])
+	if !ok {
+		return n
+	}
+	child := n.children[i].remove(rest)
+	switch {
+	case child == n.children[i]:
+		return n
+	case child == nil:
+		children := make([]*node, 0, len(n.children)-1)
+		children = append(append(children, n.children[:i]...), n.children[i+1:]...)
+		return newNode(n.label, n.terminal, n.value, children).compact()
+	}
+	return n.withChild(i, child)
+}
+
+// Return a copy of n with the ith child replaced
+func (n *node) withChild(i int, child *node) *node {
+	children := append([]*node(nil), n.children...)
+	children[i] = child
+	return newNode(n.label, n.terminal, n.value, children)
+}
+
+// Merge a non-terminal node into its only child, or return nil if it
+// has no children, so that there are no redundant nodes
+func (n *node) compact() *node {
+	if n.terminal {
+		return n
+	}
+	switch len(n.children) {
+	case 0:
+		return nil
+	case 1:
+		c := n.children[0]
+		return c.relabel(n.label + c.label)
+	}
+	return n
+}
+
+// Call f on each key and value in order, where prefix is the key of
+// n's parent, stopping if f returns false. O(n)
+func (n *node) forall(prefix string, f func(key string, value interface{}) bool) bool {
+	key := prefix + n.label
+	if n.terminal && !f(key, n.value) {
+		return false
+	}
+	for _, c := range n.children {
+		if !c.forall(key, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// Call f on each key and value in reverse order. O(n)
+func (n *node) doBackwards(prefix string, f func(key string, value interface{})) {
+	key := prefix + n.label
+	for i := len(n.children) - 1; i >= 0; i-- {
+		n.children[i].doBackwards(key, f)
+	}
+	if n.terminal {
+		f(key, n.value)
+	}
+}
+
+// Return the ith key in order, relative to prefix, and its node.
+// O(len(key) * fanout)
+func (n *node) nth(i int) (string, *node) {
+	var key strings.Builder
+	for {
+		key.WriteString(n.label)
+		if n.terminal {
+			if i == 0 {
+				return key.String(), n
+			}
+			i--
+		}
+		for _, c := range n.children {
+			if i < c.size {
+				n = c
+				break
+			}
+			i -= c.size
+		}
+	}
+}
+
+// Return the subtree of keys starting with prefix, relabelled to be a
+// root, or nil. O(len(prefix))
+func (n *node) withPrefix(prefix string) *node {
+	key := ""
+	for n != nil {
+		if strings.HasPrefix(n.label, prefix) {
+			if key == "" {
+				return n
+			}
+			return n.relabel(key + n.label)
+		}
+		if !strings.HasPrefix(prefix, n.label) {
+			return nil
+		}
+		key += n.label
+		prefix = prefix[len(n.label):]
+		i, ok := n.find(prefix[0])
+		if !ok {
+			return nil
+		}
+		n = n.children[i]
+	}
+	return nil
+}
+
+// Return the node of the longest key that is a prefix of s, and that
+// key. O(len(s))
+func (n *node) longestPrefixOf(s string) (string, *node) {
+	var best *node
+	key, bestKey := "", ""
+	for n != nil && strings.HasPrefix(s[len(key):], n.label) {
+		key += n.label
+		if n.terminal {
+			best, bestKey = n, key
+		}
+		if len(key) == len(s) {
+			break
+		}
+		i, ok := n.find(s[len(key)])
+		if !ok {
+			break
+		}
+		n = n.children[i]
+	}
+	return bestKey, best
+}
+
+// Split into two roots of about half the keys each, or nil for the
+// back if there is only one key. O(len(key))
+func (n *node) split() (front, back *node) {
+	switch {
+	case n.size < 2:
+		return n, nil
+	case !n.terminal && len(n.children) == 1:
+		c := n.children[0]
+		return c.relabel(n.label + c.label).split()
+	case n.terminal && len(n.children) == 1:
+		c := n.children[0]
+		return newNode(n.label, true, n.value, nil), c.relabel(n.label + c.label)
+	}
+	half := len(n.children) / 2
+	front = newNode(n.label, n.terminal, n.value, n.children[:half]).compact()
+	back = newNode(n.label, false, nil, n.children[half:]).compact()
+	return
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,239 @@
+// The trie package provides sets and maps of strings as persistent
+// compressed tries, also called radix trees.  Keys are kept in
+// lexicographic byte order, and all the keys with a given prefix can be
+// found in time proportional to the length of the prefix.  Versions
+// share all the nodes that are not on the path to a changed key.
+package trie
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"io"
+)
+
+// Create a new trie containing the arguments, which must be strings.
+// O(n*k) for n strings of length k
+func New(item ...interface{}) immut.Seq {
+	xs := &Trie{}
+	for _, x := range item {
+		xs = xs.add(x)
+	}
+	return xs
+}
+
+// A Seq of strings implemented as a radix tree, which may be empty
+type Trie struct {
+	root *node // nil if empty
+}
+
+// WithPrefix returns the strings that start with the prefix, sharing
+// them with this trie. O(len(prefix))
+func (xs *Trie) WithPrefix(prefix string) immut.Seq {
+	if xs.root == nil {
+		return xs
+	}
+	return &Trie{xs.root.withPrefix(prefix)}
+}
+
+// LongestPrefixOf returns the longest string in the trie that is a
+// prefix of s, and whether there is any. O(len(s))
+func (xs *Trie) LongestPrefixOf(s string) (string, bool) {
+	key, n := xs.root.longestPrefixOf(s)
+	return key, n != nil
+}
+
+// Everything below here is private
+
+func key(x interface{}) string {
+	s, ok := x.(string)
+	if !ok {
+		panic(fmt.Sprintf("trie: %T item %v is not a string", x, x))
+	}
+	return s
+}
+
+// O(len(x))
+func (xs *Trie) add(x interface{}) *Trie {
+	root, added := xs.root.insert(key(x), nil)
+	if !added {
+		return xs
+	}
+	return &Trie{root}
+}
+
+// O(1)
+func (xs *Trie) Len() int {
+	if xs.root == nil {
+		return 0
+	}
+	return xs.root.size
+}
+
+// O(k*fanout) for the ith string of length k
+func (xs *Trie) Get(i int) (interface{}, bool) {
+	if i < 0 || i >= xs.Len() {
+		return nil, false
+	}
+	s, _ := xs.root.nth(i)
+	return s, true
+}
+
+// O(len(x))
+func (xs *Trie) Contains(x interface{}) bool {
+	s, ok := x.(string)
+	if !ok {
+		return false
+	}
+	n := xs.root.lookup(s)
+	return n != nil && n.terminal
+}
+
+// O(k)
+func (xs *Trie) Front() interface{} {
+	if xs.root == nil {
+		panic("getting Front of empty seq")
+	}
+	s, _ := xs.root.nth(0)
+	return s
+}
+
+// O(k)
+func (xs *Trie) Back() interface{} {
+	if xs.root == nil {
+		panic("getting Back of empty seq")
+	}
+	s, _ := xs.root.nth(xs.root.size - 1)
+	return s
+}
+
+// O(k)
+func (xs *Trie) Rest() immut.Seq {
+	if xs.root == nil {
+		panic("getting Rest of empty seq")
+	}
+	return xs.Remove(xs.Front())
+}
+
+// O(1)
+func (xs *Trie) IsEmpty() bool { return xs.root == nil }
+
+// O(n)
+func (xs *Trie) Do(f func(interface{})) {
+	xs.Forall(func(x interface{}) bool {
+		f(x)
+		return true
+	})
+}
+
+// O(n)
+func (xs *Trie) DoBackwards(f func(interface{})) {
+	if xs.root != nil {
+		xs.root.doBackwards("", func(s string, _ interface{}) { f(s) })
+	}
+}
+
+// O(n)
+func (xs *Trie) Join(sep string, out io.Writer) {
+	s := ""
+	xs.Do(func(x interface{}) {
+		fmt.Fprintf(out, "%s%v", s, x)
+		s = sep
+	})
+}
+
+// Cannot reverse a sorted set, so just return the set itself
+func (xs *Trie) Reverse() immut.Seq { return xs }
+
+// Kept sorted, so an immut.Set
+func (xs *Trie) IsSet() {}
+
+// O(len(x))
+func (xs *Trie) AddFront(x interface{}) immut.Seq { return xs.add(x) }
+
+// O(len(x))
+func (xs *Trie) AddBack(x interface{}) immut.Seq { return xs.add(x) }
+
+// O(m*k) when adding m strings of length k
+func (xs *Trie) AddAll(that immut.Seq) immut.Seq {
+	ys := xs
+	that.Do(func(x interface{}) { ys = ys.add(x) })
+	return ys
+}
+
+// O(n), visiting strings in order
+func (xs *Trie) Forall(f func(interface{}) bool) bool {
+	return xs.root == nil ||
+		xs.root.forall("", func(s string, _ interface{}) bool { return f(s) })
+}
+
+// O(n*k), panicking if f returns something that is not a string
+func (xs *Trie) Map(f func(interface{}) interface{}) immut.Seq {
+	ys := &Trie{}
+	xs.Do(func(x interface{}) { ys = ys.add(f(x)) })
+	return ys
+}
+
+// O(n + k*len(x)) to drop k items x, sharing the subtrees where nothing
+// is filtered out
+func (xs *Trie) Filter(f func(interface{}) bool) immut.Seq {
+	var ys immut.Seq = xs
+	xs.Do(func(x interface{}) {
+		if !f(x) {
+			ys = ys.Remove(x)
+		}
+	})
+	return ys
+}
+
+// O(len(x))
+func (xs *Trie) Remove(x interface{}) immut.Seq {
+	s, ok := x.(string)
+	if !ok || xs.root == nil {
+		return xs
+	}
+	root := xs.root.remove(s)
+	if root == xs.root {
+		return xs
+	}
+	return &Trie{root}
+}
+
+// O(n)
+func (xs *Trie) Items() []interface{} {
+	ys := make([]interface{}, 0, xs.Len())
+	xs.Do(func(x interface{}) { ys = append(ys, x) })
+	return ys
+}
+
+// Split into two tries of the strings before and after some point,
+// sharing the subtrees. O(k)
+func (xs *Trie) Split() (immut.Seq, immut.Seq) {
+	if xs.root == nil {
+		return xs, xs
+	}
+	front, back := xs.root.split()
+	return &Trie{front}, &Trie{back}
+}
+
+func (xs *Trie) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	xs.Join(",", &buf)
+	buf.WriteString("}")
+	return buf.String()
+}
@@ -0,0 +1,35 @@
+package unionfind_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut/unionfind"
+)
+
+func Example() {
+	u := unionfind.New(6).Union(0, 1).Union(2, 3)
+	before := u
+	u = u.Union(1, 3).Union(4, 5)
+
+	fmt.Println(u.Connected(0, 2), u.Count(), u.Components())
+	fmt.Println(before.Connected(0, 2), before.Count(), before.Components())
+	// Output:
+	// true 2 [{0,1,2,3},{4,5}]
+	// false 4 [{0,1},{2,3},{4},{5}]
+}
+
+func ExampleUnionFind_Union_undo() {
+	// a solver tries an assumption and backtracks by keeping the old version
+	vars := unionfind.New(4).Union(0, 1)
+	for _, guess := range [][2]int{{1, 2}, {2, 3}} {
+		trial := vars.Union(guess[0], guess[1])
+		if trial.Connected(0, 3) {
+			fmt.Println("conflict, undoing", guess)
+			continue
+		}
+		vars = trial
+	}
+	fmt.Println(vars.Components())
+	// Output:
+	// conflict, undoing [2 3]
+	// [{0,1,2},{3}]
+}
@@ -0,0 +1,139 @@
+// The unionfind package provides persistent disjoint sets of the ints
+// from 0 up to some size, after Conchon and Filliâtre (2007).  Parents
+// and ranks are kept in persistent int maps, so a union makes a new
+// version in O(log n) and leaves the old one valid.  Find compresses the
+// paths it follows by replacing the parents map with an equivalent
+// shorter-pathed one, which changes nothing that can be observed, so
+// versions can still be shared between goroutines.
+package unionfind
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/intmap"
+	"github.com/eobrain/immut/intset"
+	"github.com/eobrain/immut/vector"
+	"sync/atomic"
+)
+
+// Create n disjoint sets, each containing one of the ints from 0 up to
+// but not including n. O(1)
+func New(n int) *UnionFind {
+	u := &UnionFind{n: n, rank: &intmap.Map{}, count: n}
+	u.parent.Store(&intmap.Map{})
+	return u
+}
+
+// A UnionFind is a partition of the ints from 0 up to Len into disjoint
+// sets, each represented by one of its members.
+type UnionFind struct {
+	n      int
+	parent atomic.Pointer[intmap.Map] // from each non-representative to a member closer to it
+	rank   *intmap.Map                // bound on the height of the trees of representatives, if not 0
+	count  int                        // number of sets
+}
+
+// Len is the number of ints partitioned. O(1)
+func (u *UnionFind) Len() int { return u.n }
+
+// Count is the number of disjoint sets. O(1)
+func (u *UnionFind) Count() int { return u.count }
+
+// Find returns the representative of the set containing x, panicking
+// with an *immut.IndexError if x is out of range. O(log n)
+func (u *UnionFind) Find(x int) int {
+	u.check(x)
+	parent := u.parent.Load()
+	root, path := x, []int(nil)
+	for {
+		p, ok := parent.Get(root)
+		if !ok {
+			break
+		}
+		path = append(path, root)
+		root = p.(int)
+	}
+	if len(path) > 1 {
+		// point everything on the path straight at the root
+		compressed := parent
+		for _, y := range path[:len(path)-1] {
+			compressed = compressed.Assoc(y, root).(*intmap.Map)
+		}
+		u.parent.CompareAndSwap(parent, compressed)
+	}
+	return root
+}
+
+// Connected is whether a and b are in the same set. O(log n)
+func (u *UnionFind) Connected(a, b int) bool { return u.Find(a) == u.Find(b) }
+
+// Union returns a new version with the sets containing a and b merged
+// into one, or u itself if they are already the same set. O(log n)
+func (u *UnionFind) Union(a, b int) *UnionFind {
+	ra, rb := u.Find(a), u.Find(b)
+	if ra == rb {
+		return u
+	}
+	rankA, rankB := u.rankOf(ra), u.rankOf(rb)
+	if rankA < rankB {
+		ra, rb, rankA, rankB = rb, ra, rankB, rankA
+	}
+	// rb goes under ra, the root of the taller tree
+	rank := u.rank.Dissoc(rb).(*intmap.Map)
+	if rankA == rankB {
+		rank = rank.Assoc(ra, rankA+1).(*intmap.Map)
+	}
+	v := &UnionFind{n: u.n, rank: rank, count: u.count - 1}
+	v.parent.Store(u.parent.Load().Assoc(rb, ra).(*intmap.Map))
+	return v
+}
+
+// Components returns the disjoint sets, as a vector of intset sets in
+// order of their smallest members. O(n*log n)
+func (u *UnionFind) Components() immut.Seq {
+	var members [][]uint64
+	index := make(map[int]int, u.count) // from representative to index in members
+	for x := 0; x < u.n; x++ {
+		root := u.Find(x)
+		i, ok := index[root]
+		if !ok {
+			i = len(members)
+			index[root] = i
+			members = append(members, nil)
+		}
+		members[i] = append(members[i], uint64(x))
+	}
+	sets := make([]interface{}, len(members))
+	for i, xs := range members {
+		sets[i] = intset.Of(xs...)
+	}
+	return vector.New(sets...)
+}
+
+// Everything below here is private
+
+func (u *UnionFind) check(x int) {
+	if x < 0 || x >= u.n {
+		panic(&immut.IndexError{Index: x, Len: u.n})
+	}
+}
+
+func (u *UnionFind) rankOf(root int) int {
+	if r, ok := u.rank.Get(root); ok {
+		return r.(int)
+	}
+	return 0
+}
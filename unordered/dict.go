@@ -0,0 +1,98 @@
+package unordered
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+)
+
+// Create a new unordered map from alternating keys and values.
+func NewMap(keyValues ...interface{}) immut.Map {
+	if len(keyValues)%2 != 0 {
+		panic("odd number of arguments to NewMap")
+	}
+	m := make(hashMap, len(keyValues)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		m[keyValues[i]] = keyValues[i+1]
+	}
+	return m
+}
+
+// Everything below here is private
+
+type hashMap map[interface{}]interface{}
+
+// O(1)
+func (m hashMap) Len() int { return len(m) }
+
+// O(1)
+func (m hashMap) Get(key interface{}) (interface{}, bool) {
+	x, ok := m[key]
+	return x, ok
+}
+
+// O(n)
+func (m hashMap) Assoc(key, value interface{}) immut.Map {
+	n := make(hashMap, len(m)+1)
+	for k, v := range m {
+		n[k] = v
+	}
+	n[key] = value
+	return n
+}
+
+// O(n)
+func (m hashMap) Dissoc(key interface{}) immut.Map {
+	if _, ok := m[key]; !ok {
+		return m
+	}
+	n := make(hashMap, len(m))
+	for k, v := range m {
+		if k != key {
+			n[k] = v
+		}
+	}
+	return n
+}
+
+// O(n)
+func (m hashMap) Keys() immut.Seq {
+	keys := make([]interface{}, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return New(keys...)
+}
+
+// O(n)
+func (m hashMap) Do(f func(key, value interface{})) {
+	for k, v := range m {
+		f(k, v)
+	}
+}
+
+func (m hashMap) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	sep := ""
+	for k, v := range m {
+		fmt.Fprintf(&buf, "%s%v:%v", sep, k, v)
+		sep = ","
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
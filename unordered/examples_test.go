@@ -0,0 +1,68 @@
+package unordered_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/unordered"
+	"hash/fnv"
+	"reflect"
+)
+
+// A key that Go maps reject, because it contains a slice
+type account struct {
+	ID   string
+	Tags []string
+}
+
+func ExampleNewHashedMap() {
+	byID := unordered.HashFunc(
+		func(x interface{}) uint64 {
+			h := fnv.New64a()
+			h.Write([]byte(x.(account).ID))
+			return h.Sum64()
+		},
+		func(x, y interface{}) bool { return reflect.DeepEqual(x, y) })
+
+	alice := account{"a1", []string{"admin"}}
+	bob := account{"b2", nil}
+	balances := unordered.NewHashedMap(byID, alice, 100, bob, 20)
+	balances = balances.Assoc(bob, 25)
+
+	fmt.Println(balances.Get(account{"b2", nil}))
+	fmt.Println(balances.Get(account{"a1", []string{"admin"}}))
+	fmt.Println(balances.Get(account{"a1", nil}))
+	fmt.Println(balances.(*unordered.HashedMap).Stats())
+	// Output:
+	// 25 true
+	// 100 true
+	// <nil> false
+	// {2 2 1 2 0 0}
+}
+
+func ExampleHashed_Stats() {
+	// a poor hash function, so that many items collide
+	mod10 := unordered.HashFunc(
+		func(x interface{}) uint64 { return uint64(x.(int) % 10) },
+		func(x, y interface{}) bool { return x == y })
+	xs := unordered.NewHashed(mod10)
+	for i := 0; i < 1000; i++ {
+		xs = xs.AddFront(i)
+	}
+	xs = xs.Remove(7)
+	fmt.Println(xs.Len(), xs.Contains(7), xs.Contains(17))
+	fmt.Printf("%+v\n", xs.(*unordered.Hashed).Stats())
+	// Output:
+	// 999 false true
+	// {Len:999 Depth:2 Branches:1 Leaves:0 Collisions:10 Collided:999}
+}
+
+func ExampleHashed_Split() {
+	// every item collides, so they all sit in one collision node
+	same := unordered.HashFunc(
+		func(interface{}) uint64 { return 42 },
+		func(x, y interface{}) bool { return x == y })
+	xs := unordered.NewHashed(same, 1, 2, 3, 4, 5)
+	front, back := xs.(immut.Splitter).Split()
+	fmt.Println(front.Len(), back.Len())
+	// Output: 2 3
+}
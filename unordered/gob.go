@@ -0,0 +1,48 @@
+package unordered
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"errors"
+	"github.com/eobrain/immut/internal/gobitems"
+)
+
+func init() {
+	gobitems.Register("unordered.unordered", unordered{})
+	gobitems.Register("unordered.empty", empty{})
+	gobitems.Register("unordered.hashMap", hashMap{})
+}
+
+// GobEncode writes the items of the set.
+func (xs unordered) GobEncode() ([]byte, error) { return gobitems.Encode(xs.Items()) }
+
+// GobDecode rebuilds the set from its items.
+func (xs *unordered) GobDecode(data []byte) error {
+	items, err := gobitems.Decode(data)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return errors.New("unordered: no items to decode into non-empty set")
+	}
+	*xs = make(unordered, len(items))
+	for _, x := range items {
+		(*xs)[x] = true
+	}
+	return nil
+}
+
+func (empty) GobEncode() ([]byte, error) { return []byte{}, nil }
+func (*empty) GobDecode([]byte) error    { return nil }
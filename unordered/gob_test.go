@@ -0,0 +1,39 @@
+package unordered_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/unordered"
+	"testing"
+)
+
+func roundTrip(t *testing.T, xs immut.Seq) immut.Seq {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&xs); err != nil {
+		t.Fatal(err)
+	}
+	var ys immut.Seq
+	if err := gob.NewDecoder(&buf).Decode(&ys); err != nil {
+		t.Fatal(err)
+	}
+	return ys
+}
+
+func TestGobEmpty(t *testing.T) {
+	ys := roundTrip(t, unordered.New())
+	if !ys.IsEmpty() || ys.Len() != 0 {
+		t.Errorf("decoded %v, want empty set", ys)
+	}
+	emptied := unordered.New(1).Remove(1)
+	if ys := roundTrip(t, emptied); !ys.IsEmpty() {
+		t.Errorf("decoded %v, want empty set", ys)
+	}
+}
+
+func TestGobNonEmpty(t *testing.T) {
+	ys := roundTrip(t, unordered.New(1, 2, 3))
+	if ys.Len() != 3 || !ys.Contains(1) || !ys.Contains(3) {
+		t.Errorf("decoded %v, want {1,2,3}", ys)
+	}
+}
@@ -0,0 +1,364 @@
+package unordered
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"hash/maphash"
+	"math/bits"
+)
+
+// A Hasher hashes and compares the items of a hashed set or the keys of
+// a hashed map.  Items that are Equal must have the same Hash, but items
+// with the same Hash need not be Equal.
+type Hasher interface {
+	Hash(x interface{}) uint64
+	Equal(x, y interface{}) bool
+}
+
+// The Hasher used when none is given.  Like a Go map it panics on items
+// that are not comparable.
+var DefaultHasher Hasher = builtinHasher{maphash.MakeSeed()}
+
+// Create a Hasher from a hash function and an equality function.
+func HashFunc(hash func(interface{}) uint64, equal func(x, y interface{}) bool) Hasher {
+	return &funcHasher{hash, equal}
+}
+
+// Stats describes the shape of the trie behind a hashed set or map.
+type Stats struct {
+	Len        int // number of items or keys
+	Depth      int // number of levels of nodes, 0 if empty
+	Branches   int // number of branch nodes
+	Leaves     int // number of nodes holding a single item
+	Collisions int // number of nodes holding items with the same hash
+	Collided   int // number of items in collision nodes
+}
+
+// Everything below here is private
+
+type builtinHasher struct{ seed maphash.Seed }
+
+func (h builtinHasher) Hash(x interface{}) uint64 { return maphash.Comparable(h.seed, x) }
+func (builtinHasher) Equal(x, y interface{}) bool { return x == y }
+
+type funcHasher struct {
+	hash  func(interface{}) uint64
+	equal func(x, y interface{}) bool
+}
+
+func (h *funcHasher) Hash(x interface{}) uint64   { return h.hash(x) }
+func (h *funcHasher) Equal(x, y interface{}) bool { return h.equal(x, y) }
+
+// The trie takes 6 bits of the hash at each level, so a branch has at
+// most 64 children and the trie is at most 11 levels deep.
+const (
+	levelBits = 6
+	levelMask = 1<<levelBits - 1
+)
+
+// A node of a hash array mapped trie (Bagwell 2001).  Each method takes
+// the hash of the key and the shift of the hash bits for this level.
+// Branches whose only child is not a branch are collapsed into the
+// child, so a leaf can be at any level above where its hash first
+// differs from its neighbours'.
+type node interface {
+	get(h uint64, shift uint, k interface{}, hs Hasher) (interface{}, bool)
+
+	// Return the node with k associated with v, and whether k is new
+	assoc(h uint64, shift uint, k, v interface{}, hs Hasher) (node, bool)
+
+	// Return the node without k, the node itself if k is not there, or
+	// nil if nothing is left
+	dissoc(h uint64, shift uint, k interface{}, hs Hasher) node
+
+	forall(f func(k, v interface{}) bool) bool
+	nth(i int) *leaf
+	size() int
+	stats(depth int, s *Stats)
+}
+
+type branch struct {
+	bitmap   uint64 // which of the 64 slots have children
+	children []node // in slot order
+	n        int    // number of keys below here
+}
+
+type leaf struct {
+	hash  uint64
+	key   interface{}
+	value interface{}
+}
+
+// Keys with the same full hash but not Equal
+type collision struct {
+	hash    uint64
+	entries []leaf
+}
+
+func slot(h uint64, shift uint) uint64 { return uint64(1) << (h >> shift & levelMask) }
+
+// Index in children of the child in the slot
+func (b *branch) index(bit uint64) int { return bits.OnesCount64(b.bitmap & (bit - 1)) }
+
+// O(log n)
+func (b *branch) get(h uint64, shift uint, k interface{}, hs Hasher) (interface{}, bool) {
+	bit := slot(h, shift)
+	if b.bitmap&bit == 0 {
+		return nil, false
+	}
+	return b.children[b.index(bit)].get(h, shift+levelBits, k, hs)
+}
+func (l *leaf) get(h uint64, shift uint, k interface{}, hs Hasher) (interface{}, bool) {
+	if h == l.hash && hs.Equal(k, l.key) {
+		return l.value, true
+	}
+	return nil, false
+}
+func (c *collision) get(h uint64, shift uint, k interface{}, hs Hasher) (interface{}, bool) {
+	if h == c.hash {
+		for _, e := range c.entries {
+			if hs.Equal(k, e.key) {
+				return e.value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// O(log n)
+func (b *branch) assoc(h uint64, shift uint, k, v interface{}, hs Hasher) (node, bool) {
+	bit := slot(h, shift)
+	i := b.index(bit)
+	if b.bitmap&bit == 0 {
+		children := make([]node, len(b.children)+1)
+		copy(children, b.children[:i])
+		children[i] = &leaf{h, k, v}
+		copy(children[i+1:], b.children[i:])
+		return &branch{b.bitmap | bit, children, b.n + 1}, true
+	}
+	child, added := b.children[i].assoc(h, shift+levelBits, k, v, hs)
+	if child == b.children[i] {
+		return b, false
+	}
+	children := append([]node(nil), b.children...)
+	children[i] = child
+	n := b.n
+	if added {
+		n++
+	}
+	return &branch{b.bitmap, children, n}, added
+}
+func (l *leaf) assoc(h uint64, shift uint, k, v interface{}, hs Hasher) (node, bool) {
+	switch {
+	case h != l.hash:
+		return pair(shift, l, l.hash, &leaf{h, k, v}, h), true
+	case !hs.Equal(k, l.key):
+		return &collision{h, []leaf{*l, {h, k, v}}}, true
+	case immut.Identical(v, l.value):
+		return l, false
+	}
+	// like a Go map, keep the original key
+	return &leaf{h, l.key, v}, false
+}
+func (c *collision) assoc(h uint64, shift uint, k, v interface{}, hs Hasher) (node, bool) {
+	if h != c.hash {
+		return pair(shift, c, c.hash, &leaf{h, k, v}, h), true
+	}
+	for i, e := range c.entries {
+		if hs.Equal(k, e.key) {
+			if immut.Identical(v, e.value) {
+				return c, false
+			}
+			entries := append([]leaf(nil), c.entries...)
+			entries[i].value = v
+			return &collision{h, entries}, false
+		}
+	}
+	entries := append(c.entries[:len(c.entries):len(c.entries)], leaf{h, k, v})
+	return &collision{h, entries}, true
+}
+
+// Return a branch holding two nodes with different hashes
+func pair(shift uint, a node, ah uint64, b node, bh uint64) node {
+	abit, bbit := slot(ah, shift), slot(bh, shift)
+	switch {
+	case abit == bbit:
+		return &branch{abit, []node{pair(shift+levelBits, a, ah, b, bh)}, a.size() + b.size()}
+	case abit > bbit:
+		a, b = b, a
+	}
+	return &branch{abit | bbit, []node{a, b}, a.size() + b.size()}
+}
+
+// O(log n)
+func (b *branch) dissoc(h uint64, shift uint, k interface{}, hs Hasher) node {
+	bit := slot(h, shift)
+	if b.bitmap&bit == 0 {
+		return b
+	}
+	i := b.index(bit)
+	child := b.children[i].dissoc(h, shift+levelBits, k, hs)
+	switch {
+	case child == b.children[i]:
+		return b
+	case child == nil && len(b.children) == 1:
+		return nil
+	case child == nil && len(b.children) == 2 && !isBranch(b.children[1-i]):
+		return b.children[1-i]
+	case child == nil:
+		children := make([]node, 0, len(b.children)-1)
+		children = append(append(children, b.children[:i]...), b.children[i+1:]...)
+		return &branch{b.bitmap &^ bit, children, b.n - 1}
+	case len(b.children) == 1 && !isBranch(child):
+		return child
+	}
+	children := append([]node(nil), b.children...)
+	children[i] = child
+	return &branch{b.bitmap, children, b.n - 1}
+}
+func (l *leaf) dissoc(h uint64, shift uint, k interface{}, hs Hasher) node {
+	if h == l.hash && hs.Equal(k, l.key) {
+		return nil
+	}
+	return l
+}
+func (c *collision) dissoc(h uint64, shift uint, k interface{}, hs Hasher) node {
+	if h != c.hash {
+		return c
+	}
+	for i, e := range c.entries {
+		if hs.Equal(k, e.key) {
+			if len(c.entries) == 2 {
+				other := c.entries[1-i]
+				return &other
+			}
+			entries := make([]leaf, 0, len(c.entries)-1)
+			entries = append(append(entries, c.entries[:i]...), c.entries[i+1:]...)
+			return &collision{h, entries}
+		}
+	}
+	return c
+}
+
+func isBranch(n node) bool {
+	_, ok := n.(*branch)
+	return ok
+}
+
+// O(n)
+func (b *branch) forall(f func(k, v interface{}) bool) bool {
+	for _, child := range b.children {
+		if !child.forall(f) {
+			return false
+		}
+	}
+	return true
+}
+func (l *leaf) forall(f func(k, v interface{}) bool) bool { return f(l.key, l.value) }
+func (c *collision) forall(f func(k, v interface{}) bool) bool {
+	for _, e := range c.entries {
+		if !f(e.key, e.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// O(log n)
+func (b *branch) nth(i int) *leaf {
+	for _, child := range b.children {
+		n := child.size()
+		if i < n {
+			return child.nth(i)
+		}
+		i -= n
+	}
+	panic("cannot happen")
+}
+func (l *leaf) nth(int) *leaf        { return l }
+func (c *collision) nth(i int) *leaf { return &c.entries[i] }
+
+// O(1)
+func (b *branch) size() int    { return b.n }
+func (*leaf) size() int        { return 1 }
+func (c *collision) size() int { return len(c.entries) }
+
+// O(n)
+func (b *branch) stats(depth int, s *Stats) {
+	s.Branches++
+	for _, child := range b.children {
+		child.stats(depth+1, s)
+	}
+}
+func (l *leaf) stats(depth int, s *Stats) {
+	s.Leaves++
+	s.Depth = max(s.Depth, depth)
+}
+func (c *collision) stats(depth int, s *Stats) {
+	s.Collisions++
+	s.Collided += len(c.entries)
+	s.Depth = max(s.Depth, depth)
+}
+
+// Add the differences between two nodes at the same level, without
+// looking inside the nodes they share
+func diffNodes(older, newer node, shift uint, hs Hasher, d *immut.Delta) {
+	switch {
+	case older == newer:
+		return
+	case older == nil:
+		newer.forall(func(k, _ interface{}) bool {
+			d.Added = append(d.Added, k)
+			return true
+		})
+		return
+	case newer == nil:
+		older.forall(func(k, _ interface{}) bool {
+			d.Removed = append(d.Removed, k)
+			return true
+		})
+		return
+	}
+	ob, ok1 := older.(*branch)
+	nb, ok2 := newer.(*branch)
+	if !ok1 || !ok2 {
+		older.forall(func(k, _ interface{}) bool {
+			if _, ok := newer.get(hs.Hash(k), shift, k, hs); !ok {
+				d.Removed = append(d.Removed, k)
+			}
+			return true
+		})
+		newer.forall(func(k, _ interface{}) bool {
+			if _, ok := older.get(hs.Hash(k), shift, k, hs); !ok {
+				d.Added = append(d.Added, k)
+			}
+			return true
+		})
+		return
+	}
+	for all := ob.bitmap | nb.bitmap; all != 0; all &= all - 1 {
+		bit := all & -all
+		diffNodes(ob.child(bit), nb.child(bit), shift+levelBits, hs, d)
+	}
+}
+
+// The child in the slot, or nil
+func (b *branch) child(bit uint64) node {
+	if b.bitmap&bit == 0 {
+		return nil
+	}
+	return b.children[b.index(bit)]
+}
@@ -0,0 +1,391 @@
+package unordered
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/eobrain/immut"
+	"io"
+)
+
+// Create a new unordered set containing the arguments, hashed by the
+// Hasher, or by DefaultHasher if it is nil.  Unlike New, this does not
+// need the items to be comparable if the Hasher does not.  It cannot
+// be encoded with gob or the codec package, as the Hasher cannot.
+// O(n*log(n))
+func NewHashed(h Hasher, item ...interface{}) immut.Seq {
+	if h == nil {
+		h = DefaultHasher
+	}
+	xs := &Hashed{h, nil}
+	for _, x := range item {
+		xs = xs.add(x)
+	}
+	return xs
+}
+
+// Create a new unordered map from alternating keys and values, with
+// keys hashed by the Hasher, or by DefaultHasher if it is nil.
+// O(n*log(n))
+func NewHashedMap(h Hasher, keyValues ...interface{}) immut.Map {
+	if len(keyValues)%2 != 0 {
+		panic("odd number of arguments to NewHashedMap")
+	}
+	if h == nil {
+		h = DefaultHasher
+	}
+	m := &HashedMap{h, nil}
+	for i := 0; i < len(keyValues); i += 2 {
+		m = m.assoc(keyValues[i], keyValues[i+1])
+	}
+	return m
+}
+
+// A Seq implemented as a hash array mapped trie, which may be empty
+type Hashed struct {
+	hasher Hasher
+	root   node // nil if empty
+}
+
+// A Map implemented as a hash array mapped trie, which may be empty
+type HashedMap struct {
+	hasher Hasher
+	root   node // nil if empty
+}
+
+// Stats describes the shape of the trie. O(n)
+func (xs *Hashed) Stats() Stats { return stats(xs.root) }
+
+// Stats describes the shape of the trie. O(n)
+func (m *HashedMap) Stats() Stats { return stats(m.root) }
+
+// Everything below here is private
+
+func stats(root node) (s Stats) {
+	if root != nil {
+		s.Len = root.size()
+		root.stats(1, &s)
+	}
+	return
+}
+
+// O(log n)
+func (xs *Hashed) add(x interface{}) *Hashed {
+	h := xs.hasher.Hash(x)
+	if xs.root == nil {
+		return &Hashed{xs.hasher, &leaf{h, x, struct{}{}}}
+	}
+	root, added := xs.root.assoc(h, 0, x, struct{}{}, xs.hasher)
+	if !added {
+		return xs
+	}
+	return &Hashed{xs.hasher, root}
+}
+
+// O(1)
+func (xs *Hashed) Len() int {
+	if xs.root == nil {
+		return 0
+	}
+	return xs.root.size()
+}
+
+// O(log n), in an arbitrary but fixed order
+func (xs *Hashed) Get(i int) (interface{}, bool) {
+	if i < 0 || i >= xs.Len() {
+		return nil, false
+	}
+	return xs.root.nth(i).key, true
+}
+
+// O(log n)
+func (xs *Hashed) Contains(x interface{}) bool {
+	if xs.root == nil {
+		return false
+	}
+	_, ok := xs.root.get(xs.hasher.Hash(x), 0, x, xs.hasher)
+	return ok
+}
+
+// O(log n)
+func (xs *Hashed) Front() interface{} {
+	if xs.root == nil {
+		panic("getting Front of empty seq")
+	}
+	return xs.root.nth(0).key
+}
+
+// O(log n)
+func (xs *Hashed) Back() interface{} {
+	if xs.root == nil {
+		panic("getting Back of empty seq")
+	}
+	return xs.root.nth(xs.root.size() - 1).key
+}
+
+// O(log n)
+func (xs *Hashed) Rest() immut.Seq {
+	if xs.root == nil {
+		panic("getting Rest of empty seq")
+	}
+	return xs.Remove(xs.Front())
+}
+
+// O(1)
+func (xs *Hashed) IsEmpty() bool { return xs.root == nil }
+
+// O(n)
+func (xs *Hashed) Do(f func(interface{})) {
+	xs.Forall(func(x interface{}) bool {
+		f(x)
+		return true
+	})
+}
+
+// O(n)
+func (xs *Hashed) DoBackwards(f func(interface{})) {
+	items := xs.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		f(items[i])
+	}
+}
+
+// O(n)
+func (xs *Hashed) Join(sep string, out io.Writer) {
+	s := ""
+	xs.Do(func(x interface{}) {
+		fmt.Fprintf(out, "%s%v", s, x)
+		s = sep
+	})
+}
+
+// Cannot reverse an unsorted set, so just return the set itself
+func (xs *Hashed) Reverse() immut.Seq { return xs }
+
+// Kept in order of hash, so an immut.Set
+func (xs *Hashed) IsSet() {}
+
+// O(log n)
+func (xs *Hashed) AddFront(x interface{}) immut.Seq { return xs.add(x) }
+
+// O(log n)
+func (xs *Hashed) AddBack(x interface{}) immut.Seq { return xs.add(x) }
+
+// O(m*log(n)) when adding m items to n
+func (xs *Hashed) AddAll(that immut.Seq) immut.Seq {
+	ys := xs
+	that.Do(func(x interface{}) { ys = ys.add(x) })
+	return ys
+}
+
+// O(n)
+func (xs *Hashed) Forall(f func(interface{}) bool) bool {
+	return xs.root == nil || xs.root.forall(func(k, _ interface{}) bool { return f(k) })
+}
+
+// O(n*log(n))
+func (xs *Hashed) Map(f func(interface{}) interface{}) immut.Seq {
+	ys := &Hashed{xs.hasher, nil}
+	xs.Do(func(x interface{}) { ys = ys.add(f(x)) })
+	return ys
+}
+
+// O(n + k*log n) to drop k items, sharing the parts of the trie where
+// nothing is filtered out
+func (xs *Hashed) Filter(f func(interface{}) bool) immut.Seq {
+	var ys immut.Seq = xs
+	xs.Do(func(x interface{}) {
+		if !f(x) {
+			ys = ys.Remove(x)
+		}
+	})
+	return ys
+}
+
+// O(log n)
+func (xs *Hashed) Remove(x interface{}) immut.Seq {
+	if xs.root == nil {
+		return xs
+	}
+	root := xs.root.dissoc(xs.hasher.Hash(x), 0, x, xs.hasher)
+	if root == xs.root {
+		return xs
+	}
+	return &Hashed{xs.hasher, root}
+}
+
+// O(n)
+func (xs *Hashed) Items() []interface{} {
+	ys := make([]interface{}, 0, xs.Len())
+	xs.Do(func(x interface{}) { ys = append(ys, x) })
+	return ys
+}
+
+// Split into two sets of about half the size, sharing the trie. O(log n)
+func (xs *Hashed) Split() (immut.Seq, immut.Seq) {
+	front, back := split(xs.root)
+	if back == nil {
+		return xs, &Hashed{xs.hasher, nil}
+	}
+	return &Hashed{xs.hasher, front}, &Hashed{xs.hasher, back}
+}
+
+// Diff returns the items removed and added between xs and a later
+// version, without looking inside the parts of the trie the two
+// versions share. O(d*log(n)) for versions that differ by d items and
+// share structure, O(n+m) otherwise
+func (xs *Hashed) Diff(newer immut.Seq) (d immut.Delta) {
+	if ys, ok := newer.(*Hashed); ok && immut.Identical(xs.hasher, ys.hasher) {
+		diffNodes(xs.root, ys.root, 0, xs.hasher, &d)
+		return
+	}
+	xs.Do(func(x interface{}) {
+		if !newer.Contains(x) {
+			d.Removed = append(d.Removed, x)
+		}
+	})
+	newer.Do(func(x interface{}) {
+		if !xs.Contains(x) {
+			d.Added = append(d.Added, x)
+		}
+	})
+	return
+}
+
+func (xs *Hashed) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	xs.Join(",", &buf)
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// Split the keys of a trie into two halves at the same level, or return
+// nil for the back if there is only one key
+func split(root node) (front, back node) {
+	switch r := root.(type) {
+	case *collision:
+		return splitCollision(r)
+	case *leaf:
+		return root, nil
+	}
+	b := root.(*branch)
+	if len(b.children) == 1 {
+		front, back = split(b.children[0])
+		if back == nil {
+			return root, nil
+		}
+		return &branch{b.bitmap, []node{front}, front.size()},
+			&branch{b.bitmap, []node{back}, back.size()}
+	}
+	half := len(b.children) / 2
+	bitmap := b.bitmap
+	for i := 0; i < half; i++ {
+		bitmap &= bitmap - 1
+	}
+	return newBranch(b.bitmap&^bitmap, b.children[:half]),
+		newBranch(bitmap, b.children[half:])
+}
+
+// Split the keys of a collision in two, either of which may be a leaf
+func splitCollision(c *collision) (front, back node) {
+	half := len(c.entries) / 2
+	part := func(entries []leaf) node {
+		if len(entries) == 1 {
+			l := entries[0]
+			return &l
+		}
+		return &collision{c.hash, entries}
+	}
+	return part(c.entries[:half:half]), part(c.entries[half:])
+}
+
+func newBranch(bitmap uint64, children []node) *branch {
+	n := 0
+	for _, child := range children {
+		n += child.size()
+	}
+	return &branch{bitmap, children, n}
+}
+
+// O(log n)
+func (m *HashedMap) assoc(key, value interface{}) *HashedMap {
+	h := m.hasher.Hash(key)
+	if m.root == nil {
+		return &HashedMap{m.hasher, &leaf{h, key, value}}
+	}
+	root, _ := m.root.assoc(h, 0, key, value, m.hasher)
+	if root == m.root {
+		return m
+	}
+	return &HashedMap{m.hasher, root}
+}
+
+// O(1)
+func (m *HashedMap) Len() int {
+	if m.root == nil {
+		return 0
+	}
+	return m.root.size()
+}
+
+// O(log n)
+func (m *HashedMap) Get(key interface{}) (interface{}, bool) {
+	if m.root == nil {
+		return nil, false
+	}
+	return m.root.get(m.hasher.Hash(key), 0, key, m.hasher)
+}
+
+// O(log n)
+func (m *HashedMap) Assoc(key, value interface{}) immut.Map { return m.assoc(key, value) }
+
+// O(log n)
+func (m *HashedMap) Dissoc(key interface{}) immut.Map {
+	if m.root == nil {
+		return m
+	}
+	root := m.root.dissoc(m.hasher.Hash(key), 0, key, m.hasher)
+	if root == m.root {
+		return m
+	}
+	return &HashedMap{m.hasher, root}
+}
+
+// A set sharing the trie of the map. O(1)
+func (m *HashedMap) Keys() immut.Seq { return &Hashed{m.hasher, m.root} }
+
+// O(n)
+func (m *HashedMap) Do(f func(key, value interface{})) {
+	if m.root != nil {
+		m.root.forall(func(k, v interface{}) bool {
+			f(k, v)
+			return true
+		})
+	}
+}
+
+func (m *HashedMap) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	sep := ""
+	m.Do(func(k, v interface{}) {
+		fmt.Fprintf(&buf, "%s%v:%v", sep, k, v)
+		sep = ","
+	})
+	buf.WriteString("}")
+	return buf.String()
+}
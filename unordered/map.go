@@ -159,6 +159,10 @@ func (empty) Join(string, io.Writer) {}
 func (xs unordered) Reverse() immut.Seq { return xs }
 func (n empty) Reverse() immut.Seq      { return n }
 
+// Kept in a Go map, so an immut.Set
+func (unordered) IsSet() {}
+func (empty) IsSet()     {}
+
 // O(n)
 func (xs unordered) AddFront(x interface{}) immut.Seq {
 	ys := make(unordered)
@@ -224,10 +228,27 @@ func (xs unordered) Filter(f func(interface{}) bool) immut.Seq {
 			ys[x] = true
 		}
 	}
+	if len(ys) == 0 {
+		return empty{}
+	}
 	return ys
 }
 func (n empty) Filter(f func(interface{}) bool) immut.Seq { return n }
 
+// Split into two sets of about half the size. O(n)
+func (xs unordered) Split() (immut.Seq, immut.Seq) {
+	front := make(unordered, len(xs)/2)
+	back := make(unordered, len(xs)-len(xs)/2)
+	for x := range xs {
+		if len(front) < len(xs)/2 {
+			front[x] = true
+		} else {
+			back[x] = true
+		}
+	}
+	return front, back
+}
+
 func (xs unordered) String() string {
 	var buf bytes.Buffer
 	buf.WriteString("{")
@@ -241,6 +262,9 @@ func (xs unordered) Remove(match interface{}) immut.Seq {
 	if !xs.Contains(match) {
 		return xs
 	}
+	if len(xs) == 1 {
+		return empty{}
+	}
 	ys := make(unordered)
 	for x := range xs {
 		if x != match {
@@ -251,6 +275,25 @@ func (xs unordered) Remove(match interface{}) immut.Seq {
 }
 func (n empty) Remove(x interface{}) immut.Seq { return n }
 
+// Diff returns the items removed and added between xs and a later
+// version. O(n+m)
+func (xs unordered) Diff(newer immut.Seq) (d immut.Delta) {
+	for x := range xs {
+		if !newer.Contains(x) {
+			d.Removed = append(d.Removed, x)
+		}
+	}
+	newer.Do(func(x interface{}) {
+		if !xs[x] {
+			d.Added = append(d.Added, x)
+		}
+	})
+	return
+}
+func (empty) Diff(newer immut.Seq) immut.Delta {
+	return immut.Delta{Added: newer.Items()}
+}
+
 func (xs unordered) Items() (ys []interface{}) {
 	ys = make([]interface{}, xs.Len())
 	i := 0
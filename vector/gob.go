@@ -0,0 +1,38 @@
+package vector
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "github.com/eobrain/immut/internal/gobitems"
+
+func init() {
+	gobitems.Register("vector.slice", slice{})
+	gobitems.Register("vector.empty", empty{})
+}
+
+// GobEncode writes the items of the vector.
+func (xs slice) GobEncode() ([]byte, error) { return gobitems.Encode(xs.Items()) }
+
+// GobDecode rebuilds the vector from its items.
+func (xs *slice) GobDecode(data []byte) error {
+	items, err := gobitems.Decode(data)
+	if err != nil {
+		return err
+	}
+	*xs = slice(items)
+	return nil
+}
+
+func (empty) GobEncode() ([]byte, error) { return []byte{}, nil }
+func (*empty) GobDecode([]byte) error    { return nil }
@@ -16,6 +16,7 @@ package vector
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/eobrain/immut"
 	"io"
@@ -31,6 +32,9 @@ func New(item ...interface{}) immut.Seq {
 
 // Create a new slice containing n repeats of x
 func Repeat(n int, x interface{}) immut.Seq {
+	if n <= 0 {
+		return empty{}
+	}
 	result := make([]interface{}, n)
 	for i := 0; i < n; i++ {
 		result[i] = x
@@ -38,6 +42,16 @@ func Repeat(n int, x interface{}) immut.Seq {
 	return slice(result)
 }
 
+// Create a new vector containing the items received from ch until it is
+// closed, or return the context's error if ctx is done first.
+func FromChan(ctx context.Context, ch <-chan interface{}) (immut.Seq, error) {
+	items, err := immut.Collect(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	return New(items...), nil
+}
+
 // Everything below here is private
 
 type slice []interface{}
@@ -163,10 +177,19 @@ func (xs slice) Filter(f func(interface{}) bool) immut.Seq {
 			ys = append(ys, x)
 		}
 	}
+	if len(ys) == 0 {
+		return empty{}
+	}
 	return ys
 }
 func (n empty) Filter(f func(interface{}) bool) immut.Seq { return n }
 
+// Split into two halves, sharing the underlying array. O(1)
+func (xs slice) Split() (immut.Seq, immut.Seq) {
+	h := len(xs) / 2
+	return xs[:h:h], xs[h:]
+}
+
 func (xs slice) String() string {
 	var buf bytes.Buffer
 	buf.WriteString("[")
@@ -183,6 +206,9 @@ func (xs slice) Remove(match interface{}) immut.Seq {
 			result = append(result, x)
 		}
 	}
+	if len(result) == 0 {
+		return empty{}
+	}
 	return result
 }
 func (n empty) Remove(interface{}) immut.Seq { return n }
@@ -0,0 +1,73 @@
+package zipper_test
+
+import (
+	"fmt"
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/list"
+	"github.com/eobrain/immut/vector"
+	"github.com/eobrain/immut/zipper"
+)
+
+func Example() {
+	// (+ (* 2 3) (- 7 4))
+	product := list.New("*", 2, 3)
+	difference := list.New("-", 7, 4)
+	ast := list.New("+", product, difference)
+
+	// change the 2 to a 5
+	loc, _ := zipper.New(ast).Down()
+	loc, _ = loc.Right()
+	loc, _ = loc.Down()
+	loc, _ = loc.Right()
+	edited := loc.Replace(5).Root().(immut.Seq)
+
+	fmt.Println(ast)
+	fmt.Println(edited)
+	third, _ := edited.Get(2)
+	fmt.Println(third == difference) // untouched, so shared
+
+	// Output:
+	// [+,[*,2,3],[-,7,4]]
+	// [+,[*,5,3],[-,7,4]]
+	// true
+}
+
+func ExampleLoc_Insert() {
+	loc, _ := zipper.New(vector.New("a", "c")).Down()
+	loc, _ = loc.Right()
+	loc, _ = loc.Insert("b")
+	loc, _ = loc.InsertRight("d")
+	fmt.Println(loc.Node(), loc.Root())
+	// Output:
+	// c [a,b,c,d]
+}
+
+func ExampleLoc_Remove() {
+	tree := list.New(1, list.New(2), 3)
+	loc, _ := zipper.New(tree).Down()
+	loc, _ = loc.Right()
+	loc, _ = loc.Down()
+	loc, _ = loc.Remove()
+	fmt.Println(loc.Node(), loc.Root())
+
+	loc, _ = zipper.New(tree).Down()
+	loc, _ = loc.Remove()
+	fmt.Println(loc.Node(), loc.Root())
+	// Output:
+	// [] [1,[],3]
+	// [2] [[2],3]
+}
+
+func ExampleLoc_Up() {
+	tree := list.New(list.New(1, 2), list.New(3))
+	loc, _ := zipper.New(tree).Down()
+	loc, _ = loc.Down()
+	loc, _ = loc.Right()
+	up, _ := loc.Up()
+	fmt.Println(up.Node(), up.IsRoot())
+	root, _ := up.Up()
+	fmt.Println(root.Node() == tree, root.IsRoot()) // nothing changed
+	// Output:
+	// [1,2] false
+	// true true
+}
@@ -0,0 +1,169 @@
+// The zipper package provides Huet zippers for navigating and editing
+// trees made of nested immut.Seq collections, such as a list of lists.
+// Editing deep inside the tree and then zooming back out rebuilds only
+// the Seqs on the path to the root, sharing everything else with the
+// original tree.
+package zipper
+
+// Copyright 2013 Eamonn O'Brien-Strain
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/eobrain/immut"
+	"github.com/eobrain/immut/list"
+)
+
+// A Loc is a location in a tree: the item in focus together with the
+// path back up to the root.  Items that are Seqs are branches and can be
+// moved down into, other items are leaves.  A Loc is immutable, so
+// moving or editing returns a new Loc and leaves the old one valid.
+type Loc struct {
+	focus interface{}
+	path  *path
+}
+
+// Create a Loc focused on the root of a tree.
+func New(root immut.Seq) Loc { return Loc{root, nil} }
+
+// Node returns the item in focus.
+func (l Loc) Node() interface{} { return l.focus }
+
+// IsBranch is whether the item in focus is a Seq.
+func (l Loc) IsBranch() bool {
+	_, ok := l.focus.(immut.Seq)
+	return ok
+}
+
+// IsRoot is whether the focus is the root of the tree.
+func (l Loc) IsRoot() bool { return l.path == nil }
+
+// Down moves to the first item of the Seq in focus, returning false if
+// the focus is a leaf or an empty Seq. O(1) for lists and vectors.
+func (l Loc) Down() (Loc, bool) {
+	xs, ok := l.focus.(immut.Seq)
+	if !ok || xs.IsEmpty() {
+		return l, false
+	}
+	return Loc{xs.Front(), &path{list.New(), xs.Rest(), l.path, xs, false}}, true
+}
+
+// Up moves to the Seq containing the focus, rebuilding it if anything
+// in it was changed, returning false at the root.
+func (l Loc) Up() (Loc, bool) {
+	p := l.path
+	if p == nil {
+		return l, false
+	}
+	if !p.changed {
+		return Loc{p.node, p.parent}, true
+	}
+	xs := p.rights.AddFront(l.focus)
+	p.lefts.Do(func(x interface{}) {
+		xs = xs.AddFront(x)
+	})
+	return Loc{xs, p.parent.change()}, true
+}
+
+// Left moves to the item before the focus, returning false if there is
+// none.
+func (l Loc) Left() (Loc, bool) {
+	p := l.path
+	if p == nil || p.lefts.IsEmpty() {
+		return l, false
+	}
+	return Loc{p.lefts.Front(),
+		&path{p.lefts.Rest(), p.rights.AddFront(l.focus), p.parent, p.node, p.changed}}, true
+}
+
+// Right moves to the item after the focus, returning false if there is
+// none.
+func (l Loc) Right() (Loc, bool) {
+	p := l.path
+	if p == nil || p.rights.IsEmpty() {
+		return l, false
+	}
+	return Loc{p.rights.Front(),
+		&path{p.lefts.AddFront(l.focus), p.rights.Rest(), p.parent, p.node, p.changed}}, true
+}
+
+// Replace returns a Loc with x in place of the focus.
+func (l Loc) Replace(x interface{}) Loc { return Loc{x, l.path.change()} }
+
+// Insert adds x as the item just before the focus, keeping the focus,
+// returning false at the root.
+func (l Loc) Insert(x interface{}) (Loc, bool) {
+	p := l.path
+	if p == nil {
+		return l, false
+	}
+	return Loc{l.focus, &path{p.lefts.AddFront(x), p.rights, p.parent, p.node, true}}, true
+}
+
+// InsertRight adds x as the item just after the focus, keeping the
+// focus, returning false at the root.
+func (l Loc) InsertRight(x interface{}) (Loc, bool) {
+	p := l.path
+	if p == nil {
+		return l, false
+	}
+	return Loc{l.focus, &path{p.lefts, p.rights.AddFront(x), p.parent, p.node, true}}, true
+}
+
+// Remove deletes the focus, moving to the item before it if there is
+// one, or else the item after it, or else the now empty Seq that
+// contained it.  Returns false at the root, which cannot be removed.
+func (l Loc) Remove() (Loc, bool) {
+	p := l.path
+	switch {
+	case p == nil:
+		return l, false
+	case !p.lefts.IsEmpty():
+		return Loc{p.lefts.Front(),
+			&path{p.lefts.Rest(), p.rights, p.parent, p.node, true}}, true
+	case !p.rights.IsEmpty():
+		return Loc{p.rights.Front(),
+			&path{p.lefts, p.rights.Rest(), p.parent, p.node, true}}, true
+	}
+	// p.rights is an empty Seq of the same kind as p.node
+	return Loc{p.rights, p.parent.change()}, true
+}
+
+// Root zooms all the way out, returning the root of the tree with all
+// the edits made along the way.
+func (l Loc) Root() interface{} {
+	for l.path != nil {
+		l, _ = l.Up()
+	}
+	return l.focus
+}
+
+// Everything below here is private
+
+type path struct {
+	lefts   immut.Seq // list of the items before the focus, nearest first
+	rights  immut.Seq // the items after the focus, as the rest of node
+	parent  *path     // nil if node is the root
+	node    immut.Seq // the Seq containing the focus
+	changed bool      // whether node needs rebuilding on the way up
+}
+
+// Return a copy of p marked as changed.
+func (p *path) change() *path {
+	if p == nil || p.changed {
+		return p
+	}
+	q := *p
+	q.changed = true
+	return &q
+}